@@ -1,30 +1,44 @@
 package bingxgo
 
 import (
-	"encoding/json"
-	"strconv"
+	"context"
 )
 
 type TradeClient struct {
 	client *Client
 }
 
+func NewTradeClient(client *Client) TradeClient {
+	return TradeClient{client: client}
+}
+
 func (c *TradeClient) CreateOrder(order OrderRequest) (*OrderResponse, error) {
+	return c.CreateOrderCtx(context.Background(), order)
+}
+
+func (c *TradeClient) CreateOrderCtx(ctx context.Context, order OrderRequest) (*OrderResponse, error) {
 	params := map[string]interface{}{
 		"symbol":       order.Symbol,
 		"side":         string(order.Side),
 		"positionSide": string(order.PositionSide),
 		"type":         string(order.Type),
-		"quantity":     strconv.FormatFloat(order.Quantity, 'f', -1, 64),
-		"price":        strconv.FormatFloat(order.Price, 'f', -1, 64),
+		"quantity":     order.Quantity,
+	}
+	if !order.Price.IsZero() {
+		params["price"] = order.Price
+	}
+	if order.ClientOrderID != "" {
+		params["clientOrderID"] = order.ClientOrderID
 	}
 
-	resp, err := c.client.sendRequest("POST", "/openApi/swap/v2/trade/order", params)
+	resp, err := do[OrderResponse](ctx, c.client, call{
+		method:   "POST",
+		endpoint: "/openApi/swap/v2/trade/order",
+		params:   params,
+		key:      "order",
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	var orderResp OrderResponse
-	err = json.Unmarshal(resp, &orderResp)
-	return &orderResp, err
+	return &resp, nil
 }
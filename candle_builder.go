@@ -0,0 +1,334 @@
+package bingxgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BarSpec selects when a CandleBuilder closes a bar. Exactly one field is
+// set, use TimeBars, VolumeBars or TickBars.
+type BarSpec struct {
+	// Interval closes bars on multiples of the interval since the Unix epoch.
+	Interval time.Duration
+	// Volume closes a bar once its volume reaches Volume. The trade reaching
+	// it belongs to the closed bar.
+	Volume Decimal
+	// Trades closes a bar after that many trades.
+	Trades int
+}
+
+// TimeBars closes a bar every interval, e.g. 5*time.Second or 3*time.Minute.
+func TimeBars(interval time.Duration) BarSpec {
+	return BarSpec{Interval: interval}
+}
+
+// VolumeBars closes a bar every volume traded.
+func VolumeBars(volume Decimal) BarSpec {
+	return BarSpec{Volume: volume}
+}
+
+// TickBars closes a bar every trades trades.
+func TickBars(trades int) BarSpec {
+	return BarSpec{Trades: trades}
+}
+
+// String names the bars, e.g. "5s", "vol:10" or "tick:100". It is used as the
+// Interval of the emitted KlineEvent.
+func (s BarSpec) String() string {
+	switch {
+	case s.Interval > 0:
+		return formatInterval(s.Interval)
+	case s.Volume.Sign() > 0:
+		return "vol:" + s.Volume.String()
+	default:
+		return fmt.Sprintf("tick:%d", s.Trades)
+	}
+}
+
+// formatInterval writes d in its largest whole unit, e.g. "3m" rather than
+// Duration's "3m0s".
+func formatInterval(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	}
+}
+
+func (s BarSpec) validate() error {
+	set := 0
+	if s.Interval > 0 {
+		set++
+	}
+	if s.Volume.Sign() > 0 {
+		set++
+	}
+	if s.Trades > 0 {
+		set++
+	}
+	if set != 1 {
+		return errors.New("bingx: bar spec needs exactly one of Interval, Volume or Trades")
+	}
+	if s.Interval > 0 && s.Interval%time.Millisecond != 0 {
+		return errors.New("bingx: bar interval must be whole milliseconds")
+	}
+	return nil
+}
+
+// KlineFunc fetches REST klines, e.g. MarketClient.GetKlinesCtx.
+type KlineFunc func(ctx context.Context, symbol, interval string, limit int) ([]Kline, error)
+
+// CandleBuilder aggregates the trade stream of a symbol into bars BingX does
+// not publish: time bars of any interval, volume bars and tick bars. Every
+// closed bar is passed to the handler as a KlineEvent whose Time is the start
+// of the interval for time bars and the first trade for the others.
+//
+// Time bars without trades are skipped. They close on a timer, CloseDelay
+// after their end, so trades stamped just before the end still count.
+type CandleBuilder struct {
+	// Backfill, when set, loads the klines of the current time bar on Start
+	// so its open, high, low and volume include trades from before Start.
+	// It applies to intervals that are whole minutes.
+	Backfill KlineFunc
+	// BackfillInterval is the one minute kline interval requested from
+	// Backfill. It defaults to the spot naming, "1min", set it to "1m" for
+	// swap klines.
+	BackfillInterval string
+	// CloseDelay is how long a time bar stays open past its end.
+	CloseDelay time.Duration
+
+	symbol  string
+	spec    BarSpec
+	stream  MarketStream
+	handler func(KlineEvent)
+
+	// emitMu orders the handler calls of trades and timers.
+	emitMu sync.Mutex
+	mu     sync.Mutex
+	bar    *Kline
+	trades int
+	// cutoff drops trades before it: trades counted by the backfill or
+	// belonging to a closed time bar.
+	cutoff int64
+	sub    *Subscription
+	stop   chan struct{}
+}
+
+// NewCandleBuilder builds bars of spec from the trades of symbol on stream
+// and passes each closed bar to handler.
+func NewCandleBuilder(symbol string, spec BarSpec, stream MarketStream, handler func(KlineEvent)) *CandleBuilder {
+	return &CandleBuilder{
+		BackfillInterval: "1min",
+		CloseDelay:       250 * time.Millisecond,
+		symbol:           symbol,
+		spec:             spec,
+		stream:           stream,
+		handler:          handler,
+	}
+}
+
+func (b *CandleBuilder) Start() error {
+	return b.StartCtx(context.Background())
+}
+
+// StartCtx backfills the current bar if configured and subscribes to the
+// trade stream.
+func (b *CandleBuilder) StartCtx(ctx context.Context) error {
+	if err := b.spec.validate(); err != nil {
+		return err
+	}
+	if err := b.backfill(ctx, time.Now()); err != nil {
+		return err
+	}
+	sub, err := b.stream.SubscribeTradesCtx(ctx, b.symbol, b.Add)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.sub = sub
+	b.stop = make(chan struct{})
+	b.mu.Unlock()
+	if b.spec.Interval > 0 {
+		go b.closeOnTimer(b.stop)
+	}
+	return nil
+}
+
+// Close unsubscribes from the trade stream. The open bar is not emitted.
+func (b *CandleBuilder) Close() error {
+	b.mu.Lock()
+	sub, stop := b.sub, b.stop
+	b.sub, b.stop = nil, nil
+	b.mu.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+	if sub == nil {
+		return nil
+	}
+	return sub.Unsubscribe()
+}
+
+// backfill seeds the current time bar with the klines it covers.
+func (b *CandleBuilder) backfill(ctx context.Context, now time.Time) error {
+	interval := b.spec.Interval
+	if b.Backfill == nil || interval <= 0 || interval%time.Minute != 0 {
+		return nil
+	}
+	start := b.barStart(now.UnixMilli())
+	limit := int((now.UnixMilli()-start)/time.Minute.Milliseconds()) + 1
+	klines, err := b.Backfill(ctx, b.symbol, b.BackfillInterval, limit)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, k := range klines {
+		if k.Time < start {
+			continue
+		}
+		if b.bar == nil {
+			bar := k
+			bar.Time = start
+			b.bar = &bar
+			continue
+		}
+		b.bar.High = maxDecimal(b.bar.High, k.High)
+		b.bar.Low = minDecimal(b.bar.Low, k.Low)
+		b.bar.Close = k.Close
+		b.bar.Volume = b.bar.Volume.Add(k.Volume)
+	}
+	b.cutoff = now.UnixMilli() + 1
+	return nil
+}
+
+// Add adds a trade to the open bar, closing it first when the trade belongs
+// to a later one. It is the handler given to the trade stream and can be
+// fed directly.
+func (b *CandleBuilder) Add(trade TradeEvent) {
+	b.emitMu.Lock()
+	defer b.emitMu.Unlock()
+
+	b.mu.Lock()
+	late := b.bar != nil && b.spec.Interval > 0 && trade.Timestamp < b.bar.Time
+	if trade.Timestamp < b.cutoff || late {
+		b.mu.Unlock()
+		return
+	}
+	var closed []Kline
+	if b.bar != nil && b.spec.Interval > 0 && trade.Timestamp >= b.barEnd() {
+		closed = append(closed, b.closeBar())
+	}
+
+	if b.bar == nil {
+		start := trade.Timestamp
+		if b.spec.Interval > 0 {
+			start = b.barStart(trade.Timestamp)
+		}
+		b.bar = &Kline{Open: trade.Price, High: trade.Price, Low: trade.Price, Time: start}
+	}
+	b.bar.High = maxDecimal(b.bar.High, trade.Price)
+	b.bar.Low = minDecimal(b.bar.Low, trade.Price)
+	b.bar.Close = trade.Price
+	b.bar.Volume = b.bar.Volume.Add(trade.Volume)
+	b.trades++
+
+	full := (b.spec.Volume.Sign() > 0 && !b.bar.Volume.LessThan(b.spec.Volume)) ||
+		(b.spec.Trades > 0 && b.trades >= b.spec.Trades)
+	if full {
+		closed = append(closed, b.closeBar())
+	}
+	b.mu.Unlock()
+	b.emit(closed)
+}
+
+// barStart returns the start of the time bar holding the millisecond ms.
+func (b *CandleBuilder) barStart(ms int64) int64 {
+	return ms - ms%b.spec.Interval.Milliseconds()
+}
+
+// barEnd returns the end of the open time bar. The caller holds b.mu.
+func (b *CandleBuilder) barEnd() int64 {
+	return b.bar.Time + b.spec.Interval.Milliseconds()
+}
+
+// closeBar ends the open bar and returns it. Later trades of a time bar are
+// dropped. The caller holds b.mu.
+func (b *CandleBuilder) closeBar() Kline {
+	bar := *b.bar
+	if b.spec.Interval > 0 {
+		b.cutoff = b.barEnd()
+	}
+	b.bar, b.trades = nil, 0
+	return bar
+}
+
+// closeOnTimer closes time bars after their end until stop is closed.
+func (b *CandleBuilder) closeOnTimer(stop chan struct{}) {
+	for {
+		now := time.Now()
+		end := time.UnixMilli(b.barStart(now.UnixMilli())).Add(b.spec.Interval)
+		timer := time.NewTimer(end.Add(b.CloseDelay).Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			b.advance(end)
+		}
+	}
+}
+
+// advance closes the open time bar if it ends at or before now.
+func (b *CandleBuilder) advance(now time.Time) {
+	b.emitMu.Lock()
+	defer b.emitMu.Unlock()
+
+	b.mu.Lock()
+	var closed []Kline
+	if b.bar != nil && now.UnixMilli() >= b.barEnd() {
+		closed = append(closed, b.closeBar())
+	}
+	b.mu.Unlock()
+	b.emit(closed)
+}
+
+func (b *CandleBuilder) emit(bars []Kline) {
+	for _, bar := range bars {
+		b.handler(KlineEvent{Symbol: b.symbol, Interval: b.spec.String(), Kline: bar})
+	}
+}
+
+// Current returns the open bar, false if it has no trades yet.
+func (b *CandleBuilder) Current() (Kline, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bar == nil {
+		return Kline{}, false
+	}
+	return *b.bar, true
+}
+
+func maxDecimal(a, b Decimal) Decimal {
+	if b.GreaterThan(a) {
+		return b
+	}
+	return a
+}
+
+func minDecimal(a, b Decimal) Decimal {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,152 @@
+package bingxgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func trade(ms int64, price, qty string) TradeEvent {
+	return TradeEvent{Trade: Trade{Timestamp: ms, Price: MustParseDecimal(price), Volume: MustParseDecimal(qty)}}
+}
+
+func klineStrings(k Kline) []string {
+	return []string{k.Open.String(), k.High.String(), k.Low.String(), k.Close.String(), k.Volume.String()}
+}
+
+func TestCandleBuilderTimeBars(t *testing.T) {
+	var bars []KlineEvent
+	b := NewCandleBuilder("BTC-USDT", TimeBars(5*time.Second), MarketStream{}, func(e KlineEvent) {
+		bars = append(bars, e)
+	})
+
+	b.Add(trade(10_000, "100", "1"))
+	b.Add(trade(12_000, "103", "2"))
+	b.Add(trade(14_999, "99", "1"))
+	// The next interval closes the first bar, the empty one after is skipped.
+	b.Add(trade(21_000, "101", "1"))
+	require.Len(t, bars, 1)
+	assert.Equal(t, "5s", bars[0].Interval)
+	assert.Equal(t, int64(10_000), bars[0].Time)
+	assert.Equal(t, []string{"100", "103", "99", "99", "4"}, klineStrings(bars[0].Kline))
+
+	// Trades of a closed bar are dropped.
+	b.Add(trade(14_000, "1", "1"))
+	b.advance(time.UnixMilli(25_000))
+	require.Len(t, bars, 2)
+	assert.Equal(t, int64(20_000), bars[1].Time)
+	assert.Equal(t, []string{"101", "101", "101", "101", "1"}, klineStrings(bars[1].Kline))
+	_, open := b.Current()
+	assert.False(t, open)
+}
+
+func TestCandleBuilderVolumeAndTickBars(t *testing.T) {
+	var bars []KlineEvent
+	collect := func(e KlineEvent) { bars = append(bars, e) }
+
+	b := NewCandleBuilder("BTC-USDT", VolumeBars(MustParseDecimal("3")), MarketStream{}, collect)
+	b.Add(trade(1, "100", "1"))
+	b.Add(trade(2, "102", "1.5"))
+	b.Add(trade(3, "101", "1"))
+	b.Add(trade(4, "105", "1"))
+	require.Len(t, bars, 1)
+	assert.Equal(t, "vol:3", bars[0].Interval)
+	assert.Equal(t, int64(1), bars[0].Time)
+	assert.Equal(t, []string{"100", "102", "100", "101", "3.5"}, klineStrings(bars[0].Kline))
+	current, _ := b.Current()
+	assert.Equal(t, "105", current.Open.String())
+
+	bars = nil
+	b = NewCandleBuilder("BTC-USDT", TickBars(2), MarketStream{}, collect)
+	for i, price := range []string{"1", "2", "3", "4", "5"} {
+		b.Add(trade(int64(i), price, "1"))
+	}
+	require.Len(t, bars, 2)
+	assert.Equal(t, "tick:2", bars[1].Interval)
+	assert.Equal(t, []string{"3", "4", "3", "4", "2"}, klineStrings(bars[1].Kline))
+}
+
+func TestCandleBuilderBackfill(t *testing.T) {
+	var bars []KlineEvent
+	b := NewCandleBuilder("BTC-USDT", TimeBars(3*time.Minute), MarketStream{}, func(e KlineEvent) {
+		bars = append(bars, e)
+	})
+	now := time.UnixMilli(3*60_000 + 90_000)
+	b.Backfill = func(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+		assert.Equal(t, "1min", interval)
+		assert.Equal(t, 2, limit)
+		return []Kline{
+			{Time: 2 * 60_000, Open: MustParseDecimal("90"), High: MustParseDecimal("90"), Low: MustParseDecimal("90"), Close: MustParseDecimal("90"), Volume: MustParseDecimal("9")},
+			{Time: 3 * 60_000, Open: MustParseDecimal("100"), High: MustParseDecimal("104"), Low: MustParseDecimal("98"), Close: MustParseDecimal("101"), Volume: MustParseDecimal("5")},
+			{Time: 4 * 60_000, Open: MustParseDecimal("101"), High: MustParseDecimal("102"), Low: MustParseDecimal("97"), Close: MustParseDecimal("99"), Volume: MustParseDecimal("2")},
+		}, nil
+	}
+	require.NoError(t, b.backfill(context.Background(), now))
+
+	// Stream trades up to the backfill are already in the klines.
+	b.Add(trade(now.UnixMilli(), "1000", "1"))
+	b.Add(trade(now.UnixMilli()+1, "105", "1"))
+	b.advance(time.UnixMilli(6 * 60_000))
+	require.Len(t, bars, 1)
+	assert.Equal(t, "3m", bars[0].Interval)
+	assert.Equal(t, int64(3*60_000), bars[0].Time)
+	assert.Equal(t, []string{"100", "105", "97", "105", "8"}, klineStrings(bars[0].Kline))
+}
+
+func TestCandleBuildersShareTradeStream(t *testing.T) {
+	push := make(chan struct{})
+	requests := make(chan string, 2)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req map[string]string
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 0})
+			requests <- req["reqType"]
+			if req["reqType"] == "sub" {
+				<-push
+				writeGzip(conn, []byte(`{"dataType":"BTC-USDT@trade","data":{"s":"BTC-USDT","t":"1","p":"100","q":"2","T":1700000000000}}`))
+			}
+		}
+	})
+	ws := NewWebsocketClient(url)
+	defer ws.Close()
+	spot := NewSpotMarketStream(ws)
+
+	first, second := make(chan KlineEvent, 1), make(chan KlineEvent, 1)
+	b1 := NewCandleBuilder("BTC-USDT", TickBars(1), spot, func(e KlineEvent) { first <- e })
+	b2 := NewCandleBuilder("BTC-USDT", TickBars(1), spot, func(e KlineEvent) { second <- e })
+	require.NoError(t, b1.Start())
+	require.NoError(t, b2.Start())
+	assert.Equal(t, "sub", receive(t, requests))
+
+	close(push)
+	assert.Equal(t, "100", receive(t, first).Close.String())
+	assert.Equal(t, "100", receive(t, second).Close.String())
+
+	// The stream is unsubscribed with the last builder.
+	require.NoError(t, b1.Close())
+	assert.Empty(t, requests)
+	require.NoError(t, b2.Close())
+	assert.Equal(t, "unsub", receive(t, requests))
+}
+
+func TestBarSpecString(t *testing.T) {
+	assert.Equal(t, "5s", TimeBars(5*time.Second).String())
+	assert.Equal(t, "3m", TimeBars(3*time.Minute).String())
+	assert.Equal(t, "90s", TimeBars(90*time.Second).String())
+	assert.Equal(t, "1h", TimeBars(time.Hour).String())
+	assert.Equal(t, "250ms", TimeBars(250*time.Millisecond).String())
+}
+
+func TestBarSpecValidate(t *testing.T) {
+	assert.NoError(t, TimeBars(time.Second).validate())
+	assert.Error(t, BarSpec{}.validate())
+	assert.Error(t, BarSpec{Interval: time.Second, Trades: 5}.validate())
+	assert.Error(t, TimeBars(time.Microsecond).validate())
+}
@@ -1,37 +1,60 @@
 package bingxgo
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type Client struct {
-	ApiKey      string
-	SecretKey   string
-	BaseURL     string
-	HTTPClient  *http.Client
-	Debug       bool
-	rateLimiter *RateLimiter
+	ApiKey     string
+	SecretKey  string
+	BaseURL    string
+	HTTPClient *http.Client
+	UserAgent  string
+	// Debug logs every request at debug level to stderr when no logger is
+	// configured.
+	//
+	// Deprecated: use WithDebug or WithLogger.
+	Debug bool
+	// RecvWindow is sent with every signed request when positive.
+	RecvWindow time.Duration
+	// TimeSyncInterval enables periodic server time synchronisation when positive.
+	TimeSyncInterval time.Duration
+
+	rateLimiter    *RateLimiter
+	retryPolicy    *RetryPolicy
+	timeOffset     atomic.Int64
+	lastTimeSync   atomic.Int64
+	logger         Logger
+	hooks          []Hooks
+	paramEncodings map[string]ParamEncoding
 }
 
-func NewClient(apiKey, secretKey string) *Client {
-	return &Client{
+func NewClient(apiKey, secretKey string, opts ...ClientOption) *Client {
+	c := &Client{
 		ApiKey:     apiKey,
 		SecretKey:  secretKey,
 		BaseURL:    "https://open-api.bingx.com",
 		HTTPClient: &http.Client{Timeout: 10 * time.Second},
-		Debug:      false,
+		logger:     nopLogger{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Client) SetRateLimiter(rateLimiter *RateLimiter) {
@@ -39,122 +62,306 @@ func (c *Client) SetRateLimiter(rateLimiter *RateLimiter) {
 }
 
 func (c *Client) sendRequest(method string, endpoint string, params map[string]interface{}) ([]byte, error) {
-	if len(params) == 0 {
-		return nil, fmt.Errorf("params map is nil or empty")
+	return c.sendRequestCtx(context.Background(), method, endpoint, params)
+}
+
+func (c *Client) sendRequestCtx(ctx context.Context, method string, endpoint string, params map[string]interface{}) ([]byte, error) {
+	return c.send(ctx, method, endpoint, params, true)
+}
+
+// sendPublicRequestCtx sends an unsigned request without the API key, for
+// market data endpoints that need no credentials.
+func (c *Client) sendPublicRequestCtx(ctx context.Context, method string, endpoint string, params map[string]interface{}) ([]byte, error) {
+	return c.send(ctx, method, endpoint, params, false)
+}
+
+func (c *Client) send(ctx context.Context, method string, endpoint string, params map[string]interface{}, signed bool) ([]byte, error) {
+	maxAttempts := 1
+	if isIdempotent(method, params) {
+		maxAttempts = c.retryPolicy.attempts()
+	}
+
+	timeResynced := false
+	for attempt := 1; ; attempt++ {
+		body, apiErr := c.attemptRequest(ctx, method, endpoint, params, signed, attempt)
+		if apiErr == nil {
+			return body, nil
+		}
+
+		apiErr.Attempts = attempt
+		if signed && errors.Is(apiErr, ErrInvalidTimestamp) && !timeResynced {
+			// The request was rejected before execution, so resending it
+			// with a corrected timestamp is always safe.
+			timeResynced = true
+			if err := c.SyncTime(ctx); err == nil {
+				maxAttempts++
+				continue
+			}
+		}
+		if attempt >= maxAttempts || !c.retryPolicy.shouldRetry(apiErr) {
+			return nil, apiErr
+		}
+
+		if err := sleepCtx(ctx, c.retryPolicy.backoff(attempt)); err != nil {
+			return nil, &APIError{Endpoint: endpoint, Attempts: attempt, Err: err}
+		}
+	}
+}
+
+func (c *Client) attemptRequest(ctx context.Context, method string, endpoint string, params map[string]interface{}, signed bool, attempt int) ([]byte, *APIError) {
+	limitReq := limitRequest{
+		method:   method,
+		endpoint: endpoint,
+		priority: requestPriority(ctx, method, endpoint),
+	}
+	if signed {
+		limitReq.account = accountID(c.ApiKey)
 	}
 	if c.rateLimiter != nil {
-		c.rateLimiter.Wait(endpoint)
+		if err := c.rateLimiter.acquire(ctx, limitReq); err != nil {
+			return nil, &APIError{Endpoint: endpoint, Err: err}
+		}
+	}
+
+	if signed {
+		c.maybeSyncTime(ctx)
+	}
+
+	info := &RequestInfo{
+		Method:   method,
+		Endpoint: endpoint,
+		Params:   c.requestParams(ctx, params, signed),
+		Header:   make(http.Header),
+		Attempt:  attempt,
+	}
+	if err := c.beforeSend(ctx, info); err != nil {
+		apiErr := &APIError{Endpoint: endpoint, Err: err}
+		c.onError(ctx, info, apiErr)
+		return nil, apiErr
 	}
 
 	// Build query parameters
-	encodedParams, rawParams := c.buildParams(params)
+	encodedParams, rawParams, err := c.buildParams(info.Params, signed)
+	if err != nil {
+		apiErr := &APIError{Endpoint: endpoint, Err: err}
+		c.onError(ctx, info, apiErr)
+		return nil, apiErr
+	}
 
-	// Generate signature
-	signature := c.generateSignature(rawParams)
+	header := info.Header.Clone()
+	if signed {
+		// Generate signature
+		signature := c.generateSignature(rawParams)
+		encodedParams += "&signature=" + signature
+		header.Set("X-BX-APIKEY", c.ApiKey)
+	}
 
-	// Create full URL
-	fullURL := c.buildURL(endpoint, encodedParams, signature)
+	// Create full URL, or a form body signed over the same string
+	var fullURL, body string
+	if c.paramEncoding(method, endpoint) == EncodeForm {
+		fullURL = c.BaseURL + endpoint
+		body = encodedParams
+	} else {
+		fullURL = c.buildURL(endpoint, encodedParams)
+	}
 
 	// Execute request
-	body, err := c.executeRequest(method, fullURL)
+	start := time.Now()
+	resp, err := c.executeRequest(ctx, method, fullURL, body, header)
+	latency := time.Since(start)
 	if err != nil {
-		return nil, err
+		c.log().WarnContext(ctx, "bingx request failed",
+			"method", method,
+			"endpoint", endpoint,
+			"params", redactParams(info.Params),
+			"latency", latency,
+			"error", err,
+		)
+		apiErr := &APIError{Endpoint: endpoint, Err: err}
+		c.onError(ctx, info, apiErr)
+		return nil, apiErr
+	}
+
+	apiErr := c.checkResponse(resp)
+	respInfo := &ResponseInfo{
+		StatusCode: resp.statusCode,
+		Header:     resp.header,
+		Body:       resp.body,
+		Latency:    latency,
+	}
+	if apiErr != nil {
+		respInfo.Code = apiErr.Code
+	}
+	if c.rateLimiter != nil {
+		c.rateLimiter.observe(limitReq, resp.statusCode, resp.header, respInfo.Code)
 	}
+	c.afterReceive(ctx, info, respInfo)
 
-	return body, nil
+	logAttrs := []any{
+		"method", method,
+		"endpoint", endpoint,
+		"status", resp.statusCode,
+		"latency", latency,
+	}
+	if apiErr != nil {
+		apiErr.Endpoint = endpoint
+		c.log().WarnContext(ctx, "bingx api error", append(logAttrs,
+			"code", apiErr.Code,
+			"message", apiErr.Message,
+			"params", redactParams(info.Params),
+		)...)
+		c.onError(ctx, info, apiErr)
+		return nil, apiErr
+	}
+	c.log().DebugContext(ctx, "bingx response", append(logAttrs,
+		"code", 0,
+		"body", redactBody(resp.body),
+	)...)
+	return resp.body, nil
 }
 
-func (c *Client) buildParams(params map[string]interface{}) (encoded, raw string) {
-	var encodedBuilder, rawBuilder strings.Builder
+// buildParams encodes params in key order. Signed requests get a timestamp
+// from the synchronised clock appended, replacing any caller-supplied one.
+func (c *Client) buildParams(params map[string]interface{}, signed bool) (encoded, raw string, err error) {
+	var encodedParts, rawParts []string
 
 	// Sort keys for consistent ordering
 	keys := make([]string, 0, len(params))
 	for k := range params {
+		if signed && k == "timestamp" {
+			continue
+		}
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
 	// Build both encoded and raw params
 	for _, k := range keys {
-		value := fmt.Sprintf("%v", params[k])
+		value, err := formatParam(params[k])
+		if err != nil {
+			return "", "", fmt.Errorf("error encoding param %s: %w", k, err)
+		}
 		encodedValue := url.QueryEscape(value)
 		encodedValue = strings.ReplaceAll(encodedValue, "+", "%20")
 
-		encodedBuilder.WriteString(k + "=" + encodedValue + "&")
-		rawBuilder.WriteString(k + "=" + value + "&")
+		encodedParts = append(encodedParts, k+"="+encodedValue)
+		rawParts = append(rawParts, k+"="+value)
 	}
 
 	// Add timestamp to both
-	timestamp := fmt.Sprintf("%d", time.Now().UnixMilli())
-	encodedBuilder.WriteString("timestamp=" + timestamp)
-	rawBuilder.WriteString("timestamp=" + timestamp)
+	if signed {
+		timestamp := fmt.Sprintf("%d", c.now().UnixMilli())
+		encodedParts = append(encodedParts, "timestamp="+timestamp)
+		rawParts = append(rawParts, "timestamp="+timestamp)
+	}
+
+	return strings.Join(encodedParts, "&"), strings.Join(rawParts, "&"), nil
+}
+
+func (c *Client) SignParams(params map[string]interface{}) (map[string]string, error) {
+	return c.SignParamsCtx(context.Background(), params)
+}
 
-	if c.Debug {
-		log.Printf("Raw params: %s", rawBuilder.String())
+// SignParamsCtx returns params formatted as strings with the recvWindow,
+// timestamp and signature of a signed request added. The signature covers
+// the other parameters in key order. It is meant for payloads sent with
+// WebsocketClient.RequestCtx; REST methods sign their requests themselves.
+func (c *Client) SignParamsCtx(ctx context.Context, params map[string]interface{}) (map[string]string, error) {
+	c.maybeSyncTime(ctx)
+	params = c.requestParams(ctx, params, true)
+	params["timestamp"] = strconv.FormatInt(c.now().UnixMilli(), 10)
+	_, raw, err := c.buildParams(params, false)
+	if err != nil {
+		return nil, err
 	}
 
-	return encodedBuilder.String(), rawBuilder.String()
+	signed := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		// buildParams has validated every value.
+		signed[k], _ = formatParam(v)
+	}
+	signed["signature"] = c.generateSignature(raw)
+	return signed, nil
 }
 
-func (c *Client) buildURL(endpoint, params, signature string) string {
-	fullURL := fmt.Sprintf("%s%s?%s&signature=%s",
+func (c *Client) buildURL(endpoint, params string) string {
+	if params == "" {
+		return c.BaseURL + endpoint
+	}
+	return fmt.Sprintf("%s%s?%s",
 		c.BaseURL,
 		endpoint,
 		params,
-		signature,
 	)
+}
 
-	if c.Debug {
-		log.Printf("Full URL: %s", fullURL)
-	}
-
-	return fullURL
+type apiResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
 }
 
-func (c *Client) executeRequest(method, url string) ([]byte, error) {
-	req, err := http.NewRequest(method, url, nil)
+func (c *Client) executeRequest(ctx context.Context, method, url, body string, header http.Header) (*apiResponse, error) {
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("X-BX-APIKEY", c.ApiKey)
-
-	if c.Debug {
-		log.Printf("Request Headers: %v", req.Header)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
 	}
 
+	c.log().DebugContext(ctx, "bingx request",
+		"method", method,
+		"url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path+"?"+redactQuery(req.URL.RawQuery),
+		"headers", redactHeader(req.Header),
+		"body", redactQuery(body),
+	)
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		return nil, &transportError{fmt.Errorf("error sending request: %w", redactURLError(err))}
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %v", err)
-	}
-
-	if c.Debug {
-		log.Printf("Response Body: %s", string(body))
+		return nil, &transportError{fmt.Errorf("error reading response: %w", err)}
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return c.handleErrorResponse(resp.StatusCode, body)
-	}
-
-	return body, nil
+	return &apiResponse{statusCode: resp.StatusCode, header: resp.Header, body: respBody}, nil
 }
 
-func (c *Client) handleErrorResponse(statusCode int, body []byte) ([]byte, error) {
-	var apiErr APIError
-	if err := json.Unmarshal(body, &apiErr); err != nil {
-		return nil, fmt.Errorf("http status %d (%s), body: %s",
-			statusCode,
-			http.StatusText(statusCode),
-			string(body),
+// checkResponse turns non-200 statuses and non-zero BingX codes into an APIError.
+func (c *Client) checkResponse(resp *apiResponse) *APIError {
+	apiErr := &APIError{}
+	if err := json.Unmarshal(resp.body, apiErr); err != nil {
+		if resp.statusCode == http.StatusOK {
+			// Not every endpoint wraps its payload in an envelope.
+			return nil
+		}
+		apiErr.Err = fmt.Errorf("http status %d (%s), body: %s",
+			resp.statusCode,
+			http.StatusText(resp.statusCode),
+			string(resp.body),
 		)
 	}
-	return nil, apiErr
+	if resp.statusCode == http.StatusOK && apiErr.Code == 0 {
+		return nil
+	}
+	apiErr.HTTPStatus = resp.statusCode
+	apiErr.RequestID = requestID(resp.header)
+	return apiErr
 }
 
 func (c *Client) generateSignature(queryString string) string {
@@ -162,12 +369,3 @@ func (c *Client) generateSignature(queryString string) string {
 	h.Write([]byte(queryString))
 	return hex.EncodeToString(h.Sum(nil))
 }
-
-type APIError struct {
-	Code    int    `json:"code"`
-	Message string `json:"msg"`
-}
-
-func (e APIError) Error() string {
-	return fmt.Sprintf("api error, code: %d, message: %s", e.Code, e.Message)
-}
@@ -17,9 +17,8 @@ var (
 )
 
 func init() {
-	client = NewClient(os.Getenv("API_KEY"), os.Getenv("SECRET_KEY"))
-	client.Debug = true
-	spotClient.client = client
+	client = NewClient(os.Getenv("API_KEY"), os.Getenv("SECRET_KEY"), WithDebug(true))
+	*spotClient = NewSpotClient(client)
 }
 
 func TestBalance(t *testing.T) {
@@ -30,8 +29,8 @@ func TestBalance(t *testing.T) {
 }
 
 func TestBatchOrders(t *testing.T) {
-	quantity := 100.0
-	price := 0.01584
+	quantity := MustParseDecimal("100")
+	price := MustParseDecimal("0.01584")
 
 	orders, err := spotClient.CreateBatchOrders([]SpotOrderRequest{
 		{
@@ -61,8 +60,8 @@ func TestCreateOrder(t *testing.T) {
 		Symbol:      symbol,
 		Side:        "SELL",
 		Type:        "LIMIT",
-		Quantity:    50,
-		Price:       0.05,
+		Quantity:    MustParseDecimal("50"),
+		Price:       MustParseDecimal("0.05"),
 		TimeInForce: "GTC",
 	})
 	assert.Equal(t, err, nil)
@@ -0,0 +1,326 @@
+package bingxgo
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base-10 number used for every price, quantity and
+// amount. It is stored as an unscaled integer and a count of fractional
+// digits, so values never round-trip through binary floating point. The zero
+// value is 0 and Decimals are immutable.
+//
+// Decimals encode to JSON as strings and decode from JSON strings or numbers.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// maxDecimalExponent bounds exponents and scales accepted by ParseDecimal, so
+// a hostile payload cannot allocate huge numbers or overflow the scale.
+const maxDecimalExponent = 1000
+
+var (
+	bigTen      = big.NewInt(10)
+	zeroDecimal = Decimal{}
+)
+
+func DecimalFromInt(v int64) Decimal {
+	return Decimal{value: big.NewInt(v)}
+}
+
+// DecimalFromFloat converts f using its shortest decimal representation,
+// so DecimalFromFloat(0.1) is exactly 0.1.
+func DecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+	if err != nil {
+		return zeroDecimal
+	}
+	return d
+}
+
+// ParseDecimal parses strings such as "12", "-0.015" and "1.5e-8".
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+	if s == "" {
+		return zeroDecimal, fmt.Errorf("invalid decimal %q", orig)
+	}
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return zeroDecimal, fmt.Errorf("invalid decimal %q", orig)
+		}
+		if e > maxDecimalExponent || e < -maxDecimalExponent {
+			return zeroDecimal, fmt.Errorf("decimal %q: exponent out of range", orig)
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	digits := s
+	var scale int64
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		scale = int64(len(s) - i - 1)
+	}
+	if digits == "" || digits == "-" || digits == "+" {
+		return zeroDecimal, fmt.Errorf("invalid decimal %q", orig)
+	}
+
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return zeroDecimal, fmt.Errorf("invalid decimal %q", orig)
+	}
+
+	scale -= exp
+	if scale > maxDecimalExponent || scale < -maxDecimalExponent {
+		return zeroDecimal, fmt.Errorf("decimal %q: scale out of range", orig)
+	}
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// rescale returns the unscaled value of d at a scale of at least d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale <= d.scale {
+		return d.unscaled()
+	}
+	return new(big.Int).Mul(d.unscaled(), pow10(scale-d.scale))
+}
+
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(a.scale, b.scale)
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: new(big.Int).Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: new(big.Int).Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{
+		value: new(big.Int).Mul(d.unscaled(), other.unscaled()),
+		scale: d.scale + other.scale,
+	}
+}
+
+// Div returns d / other rounded half away from zero to places fractional
+// digits. It panics if other is zero.
+func (d Decimal) Div(other Decimal, places int32) Decimal {
+	num := new(big.Int).Mul(d.unscaled(), pow10(places+other.scale))
+	den := new(big.Int).Mul(other.unscaled(), pow10(d.scale))
+	return Decimal{value: quoRound(num, den, roundHalfUp), scale: places}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.unscaled()), scale: d.scale}
+}
+
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+type roundingMode int
+
+const (
+	roundDown   roundingMode = iota // toward zero
+	roundHalfUp                     // half away from zero
+	roundFloor                      // toward negative infinity
+	roundCeil                       // toward positive infinity
+)
+
+func quoRound(num, den *big.Int, mode roundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// The sign of the exact quotient.
+	negative := (num.Sign() < 0) != (den.Sign() < 0)
+	away := false
+	switch mode {
+	case roundHalfUp:
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		away = twice.Cmp(new(big.Int).Abs(den)) >= 0
+	case roundFloor:
+		away = negative
+	case roundCeil:
+		away = !negative
+	}
+	if away {
+		if negative {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func (d Decimal) roundPlaces(places int32, mode roundingMode) Decimal {
+	if places >= d.scale {
+		return d
+	}
+	return Decimal{value: quoRound(d.unscaled(), pow10(d.scale-places), mode), scale: places}
+}
+
+// Round rounds half away from zero to places fractional digits.
+func (d Decimal) Round(places int32) Decimal {
+	return d.roundPlaces(places, roundHalfUp)
+}
+
+// Truncate drops fractional digits beyond places.
+func (d Decimal) Truncate(places int32) Decimal {
+	return d.roundPlaces(places, roundDown)
+}
+
+func (d Decimal) roundStep(step Decimal, mode roundingMode) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+	a, s, scale := align(d, step)
+	q := quoRound(a, s, mode)
+	return Decimal{value: q.Mul(q, s), scale: scale}
+}
+
+// RoundToStep rounds d to the nearest multiple of step, e.g. a symbol's
+// tick size. A non-positive step leaves d unchanged.
+func (d Decimal) RoundToStep(step Decimal) Decimal {
+	return d.roundStep(step, roundHalfUp)
+}
+
+// FloorToStep rounds d down to a multiple of step, which keeps quantities
+// within the available balance.
+func (d Decimal) FloorToStep(step Decimal) Decimal {
+	return d.roundStep(step, roundFloor)
+}
+
+// CeilToStep rounds d up to a multiple of step.
+func (d Decimal) CeilToStep(step Decimal) Decimal {
+	return d.roundStep(step, roundCeil)
+}
+
+// Float64 returns the nearest float64, for display and statistics only.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d without exponent and without trailing fractional zeros.
+func (d Decimal) String() string {
+	s := d.unscaled().String()
+	if d.scale == 0 {
+		return s
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if pad := int(d.scale) - len(s) + 1; pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	point := len(s) - int(d.scale)
+	intPart, fracPart := s[:point], strings.TrimRight(s[point:], "0")
+
+	out := intPart
+	if fracPart != "" {
+		out += "." + fracPart
+	}
+	if negative && out != "0" {
+		out = "-" + out
+	}
+	return out
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = zeroDecimal
+		return nil
+	}
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(strings.TrimSpace(s)))
+	}
+	return d.UnmarshalText(data)
+}
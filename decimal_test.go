@@ -0,0 +1,89 @@
+package bingxgo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalParseAndFormat(t *testing.T) {
+	cases := map[string]string{
+		"0":          "0",
+		"0.01584":    "0.01584",
+		"-1.500":     "-1.5",
+		"1e-8":       "0.00000001",
+		"1.5E3":      "1500",
+		"-0.0":       "0",
+		"123456.789": "123456.789",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, d.String(), in)
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "-", "1e"} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.3333", MustParseDecimal("1").Div(DecimalFromInt(3), 4).String())
+	assert.Equal(t, "0.6667", MustParseDecimal("2").Div(DecimalFromInt(3), 4).String())
+	assert.True(t, a.LessThan(b))
+	assert.True(t, a.Add(b).Equal(MustParseDecimal("0.30")))
+	assert.Equal(t, "0.1", DecimalFromFloat(0.1).String())
+}
+
+func TestDecimalRounding(t *testing.T) {
+	price := MustParseDecimal("0.015847")
+	tick := MustParseDecimal("0.00001")
+	assert.Equal(t, "0.01585", price.RoundToStep(tick).String())
+	assert.Equal(t, "0.01584", price.FloorToStep(tick).String())
+	assert.Equal(t, "0.01585", price.CeilToStep(tick).String())
+
+	qty := MustParseDecimal("12.37")
+	step := MustParseDecimal("0.5")
+	assert.Equal(t, "12", qty.FloorToStep(step).String())
+	assert.Equal(t, "12.5", qty.RoundToStep(step).String())
+	assert.Equal(t, "-12.5", qty.Neg().FloorToStep(step).String())
+
+	assert.Equal(t, "1.24", MustParseDecimal("1.235").Round(2).String())
+	assert.Equal(t, "1.23", MustParseDecimal("1.239").Truncate(2).String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+		C Decimal `json:"c"`
+		D Decimal `json:"d"`
+	}
+	err := json.Unmarshal([]byte(`{"a":"49999.00000000000000000000","b":0.01584,"c":"","d":null}`), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, "49999", v.A.String())
+	assert.Equal(t, "0.01584", v.B.String())
+	assert.True(t, v.C.IsZero())
+
+	out, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":"49999","b":"0.01584","c":"0","d":"0"}`, string(out))
+}
+
+func TestDecimalRejectsHugeExponents(t *testing.T) {
+	for _, in := range []string{"1.5e-2147483648", "1e300000000", "1e1001", "1e-1001", "0.5e-1000"} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+	assert.Equal(t, "1", MustParseDecimal("1e1000").Div(MustParseDecimal("1e1000"), 0).String())
+
+	var k Kline
+	assert.Error(t, json.Unmarshal([]byte(`{"open":"1e300000000"}`), &k))
+}
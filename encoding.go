@@ -0,0 +1,86 @@
+package bingxgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ParamEncoding selects where the signed parameters of a request are sent.
+type ParamEncoding int
+
+const (
+	// EncodeQuery sends parameters and signature in the URL query string.
+	EncodeQuery ParamEncoding = iota
+	// EncodeForm sends them as an application/x-www-form-urlencoded body.
+	// It only applies to POST, PUT and DELETE requests.
+	EncodeForm
+)
+
+// defaultParamEncodings keeps order payloads out of URLs, where they would
+// end up in proxy logs and hit length limits for batch orders.
+var defaultParamEncodings = map[string]ParamEncoding{
+	"/openApi/spot/v1/trade/order":       EncodeForm,
+	"/openApi/spot/v1/trade/batchOrders": EncodeForm,
+	"/openApi/swap/v2/trade/order":       EncodeForm,
+	"/openApi/swap/v2/trade/batchOrders": EncodeForm,
+}
+
+// SetParamEncoding overrides how parameters are sent to endpoint. It must
+// not be called concurrently with requests.
+func (c *Client) SetParamEncoding(endpoint string, encoding ParamEncoding) {
+	if c.paramEncodings == nil {
+		c.paramEncodings = make(map[string]ParamEncoding)
+	}
+	c.paramEncodings[endpoint] = encoding
+}
+
+func WithParamEncoding(endpoint string, encoding ParamEncoding) ClientOption {
+	return func(c *Client) {
+		c.SetParamEncoding(endpoint, encoding)
+	}
+}
+
+func (c *Client) paramEncoding(method, endpoint string) ParamEncoding {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return EncodeQuery
+	}
+	if encoding, ok := c.paramEncodings[endpoint]; ok {
+		return encoding
+	}
+	return defaultParamEncodings[endpoint]
+}
+
+// formatParam renders a parameter value exactly as it is signed and sent.
+// Slices, maps and structs are sent as JSON, which is what BingX expects for
+// nested values such as the batch order "data" array.
+func formatParam(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int8, int16, int32, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprintf("%v", v), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
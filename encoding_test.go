@@ -0,0 +1,70 @@
+package bingxgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchOrdersSignedFormBody(t *testing.T) {
+	c := NewClient("key", "secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.RawQuery)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.NoError(t, r.ParseForm())
+
+		// The signature covers the decoded values in key order.
+		raw := "data=" + r.PostForm.Get("data") + "&sync=true&timestamp=" + r.PostForm.Get("timestamp")
+		assert.Equal(t, c.generateSignature(raw), r.PostForm.Get("signature"))
+		assert.JSONEq(t, `[{"symbol":"BTC-USDT","side":"BUY","type":"LIMIT","quantity":"1","price":"100"}]`, r.PostForm.Get("data"))
+
+		w.Write([]byte(`{"code":0,"data":{"orders":[]}}`))
+	}))
+	defer server.Close()
+	c.BaseURL = server.URL
+
+	spot := NewSpotClient(c)
+	_, err := spot.CreateBatchOrders([]SpotOrderRequest{
+		{Symbol: "BTC-USDT", Side: "BUY", Type: "LIMIT", Quantity: DecimalFromInt(1), Price: DecimalFromInt(100)},
+	}, true)
+	assert.NoError(t, err)
+}
+
+func TestQueryEncodingOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, _ := url.ParseQuery(r.URL.RawQuery)
+		assert.Equal(t, "BTC-USDT", q.Get("symbol"))
+		assert.NotEmpty(t, q.Get("signature"))
+		w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(server.URL),
+		WithParamEncoding("/openApi/spot/v1/trade/order", EncodeQuery))
+	spot := NewSpotClient(c)
+	_, err := spot.CreateOrder(SpotOrderRequest{Symbol: "BTC-USDT", Side: "BUY", Type: "MARKET", Quantity: DecimalFromInt(1)})
+	assert.NoError(t, err)
+}
+
+func TestSignParams(t *testing.T) {
+	c := NewClient("key", "secret")
+	c.RecvWindow = 5 * time.Second
+	signed, err := c.SignParams(map[string]interface{}{
+		"symbol":   "BTC-USDT",
+		"quantity": MustParseDecimal("1.50"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC-USDT", signed["symbol"])
+	assert.Equal(t, "1.5", signed["quantity"])
+	assert.Equal(t, "5000", signed["recvWindow"])
+	assert.NotEmpty(t, signed["timestamp"])
+
+	// The signature covers every other parameter in key order.
+	raw := "quantity=1.5&recvWindow=5000&symbol=BTC-USDT&timestamp=" + signed["timestamp"]
+	assert.Equal(t, c.generateSignature(raw), signed["signature"])
+	assert.Len(t, signed, 5)
+}
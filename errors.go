@@ -0,0 +1,107 @@
+package bingxgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("bingx: insufficient balance")
+	ErrInvalidSignature    = errors.New("bingx: invalid signature")
+	ErrInvalidTimestamp    = errors.New("bingx: timestamp outside recvWindow")
+	ErrRateLimited         = errors.New("bingx: rate limited")
+	ErrSymbolOffline       = errors.New("bingx: symbol offline")
+	ErrOrderNotFound       = errors.New("bingx: order not found")
+)
+
+// errorCodes maps documented BingX error codes to their sentinel errors.
+var errorCodes = map[int]error{
+	100001: ErrInvalidSignature,
+	100412: ErrInvalidSignature,
+	100421: ErrInvalidTimestamp,
+	100202: ErrInsufficientBalance,
+	101204: ErrInsufficientBalance,
+	100410: ErrRateLimited,
+	109425: ErrSymbolOffline,
+	101415: ErrSymbolOffline,
+	80016:  ErrOrderNotFound,
+	100404: ErrOrderNotFound,
+}
+
+// requestIDHeaders are the response headers checked for a server-side request ID.
+var requestIDHeaders = []string{"X-Request-Id", "X-Trace-Id"}
+
+// APIError is returned by every client method. Code is the BingX error code
+// when the exchange answered, and Err holds the underlying transport or
+// decoding error otherwise. Use errors.Is with the Err* sentinels to classify
+// it.
+type APIError struct {
+	HTTPStatus int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+	DebugMsg   string `json:"debugMsg"`
+	Endpoint   string `json:"-"`
+	RequestID  string `json:"-"`
+	Attempts   int    `json:"-"`
+	Err        error  `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.Err != nil:
+		b.WriteString(e.Err.Error())
+	case e.Code != 0:
+		fmt.Fprintf(&b, "api error, code: %d, message: %s", e.Code, e.Message)
+	default:
+		fmt.Fprintf(&b, "http status %d (%s)", e.HTTPStatus, http.StatusText(e.HTTPStatus))
+	}
+	if e.DebugMsg != "" {
+		fmt.Fprintf(&b, ", debugMsg: %s", e.DebugMsg)
+	}
+	if e.HTTPStatus != 0 && e.HTTPStatus != http.StatusOK && (e.Err != nil || e.Code != 0) {
+		fmt.Fprintf(&b, ", http status: %d", e.HTTPStatus)
+	}
+	if e.Endpoint != "" {
+		fmt.Fprintf(&b, ", endpoint: %s", e.Endpoint)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, ", request id: %s", e.RequestID)
+	}
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, ", attempts: %d", e.Attempts)
+	}
+	return b.String()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+func (e *APIError) Is(target error) bool {
+	if target == ErrRateLimited && e.HTTPStatus == http.StatusTooManyRequests {
+		return true
+	}
+	sentinel, ok := errorCodes[e.Code]
+	return ok && sentinel == target
+}
+
+// ErrorCode returns the BingX error code carried by err, or 0 if there is none.
+func ErrorCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func requestID(header http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
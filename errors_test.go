@@ -0,0 +1,37 @@
+package bingxgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Write([]byte(`{"code":100202,"msg":"Insufficient assets","debugMsg":""}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(server.URL))
+	spot := NewSpotClient(c)
+
+	_, err := spot.CreateOrder(SpotOrderRequest{Symbol: "BTC-USDT", Side: "BUY", Type: "MARKET", Quantity: DecimalFromInt(1)})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.NotErrorIs(t, err, ErrRateLimited)
+
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "/openApi/spot/v1/trade/order", apiErr.Endpoint)
+	assert.Equal(t, "req-1", apiErr.RequestID)
+	assert.Equal(t, 100202, ErrorCode(fmt.Errorf("wrapped: %w", err)))
+}
+
+func TestAPIErrorRateLimitedStatus(t *testing.T) {
+	err := &APIError{HTTPStatus: http.StatusTooManyRequests}
+	assert.True(t, errors.Is(err, ErrRateLimited))
+}
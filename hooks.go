@@ -0,0 +1,74 @@
+package bingxgo
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes one attempt of a REST call. BeforeSend hooks may
+// modify Params and Header; signing happens after all hooks have run.
+type RequestInfo struct {
+	Method   string
+	Endpoint string
+	Params   map[string]interface{}
+	Header   http.Header
+	Attempt  int
+}
+
+type ResponseInfo struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Latency    time.Duration
+	// Code is the decoded BingX code, zero on success.
+	Code int
+}
+
+// Hooks wraps every REST call made by Client. Any field may be nil. A
+// BeforeSend error aborts the request without sending it and is never
+// retried.
+type Hooks struct {
+	BeforeSend   func(ctx context.Context, req *RequestInfo) error
+	AfterReceive func(ctx context.Context, req *RequestInfo, resp *ResponseInfo)
+	OnError      func(ctx context.Context, req *RequestInfo, err error)
+}
+
+// Use registers hooks, which run in registration order. It must not be
+// called concurrently with requests.
+func (c *Client) Use(hooks ...Hooks) {
+	c.hooks = append(c.hooks, hooks...)
+}
+
+func WithHooks(hooks ...Hooks) ClientOption {
+	return func(c *Client) {
+		c.Use(hooks...)
+	}
+}
+
+func (c *Client) beforeSend(ctx context.Context, req *RequestInfo) error {
+	for _, h := range c.hooks {
+		if h.BeforeSend != nil {
+			if err := h.BeforeSend(ctx, req); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) afterReceive(ctx context.Context, req *RequestInfo, resp *ResponseInfo) {
+	for _, h := range c.hooks {
+		if h.AfterReceive != nil {
+			h.AfterReceive(ctx, req, resp)
+		}
+	}
+}
+
+func (c *Client) onError(ctx context.Context, req *RequestInfo, err error) {
+	for _, h := range c.hooks {
+		if h.OnError != nil {
+			h.OnError(ctx, req, err)
+		}
+	}
+}
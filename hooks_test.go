@@ -0,0 +1,66 @@
+package bingxgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooksSeeEveryAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "audit", r.Header.Get("X-Audit"))
+		assert.Equal(t, "1", r.URL.Query().Get("injected"))
+		w.Write([]byte(`{"code":80016,"msg":"order does not exist"}`))
+	}))
+	defer server.Close()
+
+	var (
+		before   *RequestInfo
+		received *ResponseInfo
+		failed   error
+	)
+	c := NewClient("key", "secret", WithBaseURL(server.URL), WithHooks(Hooks{
+		BeforeSend: func(ctx context.Context, req *RequestInfo) error {
+			before = req
+			req.Header.Set("X-Audit", "audit")
+			req.Params["injected"] = "1"
+			return nil
+		},
+		AfterReceive: func(ctx context.Context, req *RequestInfo, resp *ResponseInfo) {
+			received = resp
+		},
+		OnError: func(ctx context.Context, req *RequestInfo, err error) {
+			failed = err
+		},
+	}))
+
+	spot := NewSpotClient(c)
+	err := spot.CancelOrder("BTC-USDT", "1")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+
+	assert.Equal(t, "/openApi/spot/v1/trade/cancel", before.Endpoint)
+	assert.Equal(t, "BTC-USDT", before.Params["symbol"])
+	assert.Equal(t, 80016, received.Code)
+	assert.Equal(t, http.StatusOK, received.StatusCode)
+	assert.ErrorIs(t, failed, ErrOrderNotFound)
+}
+
+func TestBeforeSendErrorAbortsRequest(t *testing.T) {
+	chaos := errors.New("chaos")
+	calls := 0
+	c := NewClient("key", "secret", WithBaseURL("http://127.0.0.1:0"), WithHooks(Hooks{
+		BeforeSend: func(ctx context.Context, req *RequestInfo) error {
+			calls++
+			return chaos
+		},
+	}))
+	c.SetRetryPolicy(DefaultRetryPolicy())
+
+	_, err := c.sendRequest("GET", "/test", nil)
+	assert.ErrorIs(t, err, chaos)
+	assert.Equal(t, 1, calls)
+}
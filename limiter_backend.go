@@ -0,0 +1,180 @@
+package bingxgo
+
+import (
+	"sync"
+	"time"
+)
+
+// BucketSpec describes a token bucket as configured by the RateLimiter.
+// Buckets with zero capacity hold no tokens and only gate through pauses.
+type BucketSpec struct {
+	Key      string
+	Capacity float64
+	// Rate is the refill rate in tokens per second.
+	Rate float64
+}
+
+// BucketState is the stored state of one bucket.
+type BucketState struct {
+	Key         string
+	Tokens      float64
+	Capacity    float64
+	PausedUntil time.Time
+}
+
+// LimiterBackend stores the token buckets behind a RateLimiter. Every method
+// must be atomic with respect to other callers sharing the same backend,
+// including callers in other processes for shared backends.
+type LimiterBackend interface {
+	// Take removes n tokens, capped at each bucket's capacity, from every
+	// bucket if all of them have enough and none is paused. Otherwise it
+	// removes nothing and returns how long to wait before trying again,
+	// along with the keys of the buckets that blocked the request.
+	Take(now time.Time, n float64, buckets []BucketSpec) (time.Duration, []string, error)
+	// Pause blocks the bucket until the given time.
+	Pause(key string, until time.Time) error
+	// Clamp lowers the tokens of the buckets to at most remaining.
+	Clamp(now time.Time, remaining float64, buckets []BucketSpec) error
+	// Snapshot returns the refilled state of every known bucket.
+	Snapshot(now time.Time) ([]BucketState, error)
+}
+
+// bucketRecord is the persisted form of a bucket, shared by all backends.
+type bucketRecord struct {
+	Tokens      float64 `json:"tokens"`
+	Last        int64   `json:"last"` // unix nanoseconds of the last refill
+	Filled      bool    `json:"filled"`
+	Capacity    float64 `json:"capacity"`
+	Rate        float64 `json:"rate"`
+	PausedUntil int64   `json:"pausedUntil,omitempty"`
+}
+
+type bucketTable map[string]*bucketRecord
+
+// refill returns the bucket for spec, brought up to date at now. New buckets
+// start full.
+func (t bucketTable) refill(spec BucketSpec, now time.Time) *bucketRecord {
+	b, ok := t[spec.Key]
+	if !ok {
+		b = &bucketRecord{}
+		t[spec.Key] = b
+	}
+	b.Capacity, b.Rate = spec.Capacity, spec.Rate
+	if !b.Filled {
+		b.Tokens, b.Filled = b.Capacity, true
+		b.Last = now.UnixNano()
+	} else if now.UnixNano() > b.Last {
+		// now may predate Last when it was taken before waiting for the
+		// backend lock, moving Last back would credit the interval twice.
+		b.Tokens += now.Sub(time.Unix(0, b.Last)).Seconds() * b.Rate
+		b.Last = now.UnixNano()
+	}
+	b.Tokens = min(b.Tokens, b.Capacity)
+	return b
+}
+
+func (t bucketTable) take(now time.Time, n float64, specs []BucketSpec) (time.Duration, []string) {
+	var (
+		wait    time.Duration
+		blocked []string
+	)
+	for _, spec := range specs {
+		b := t.refill(spec, now)
+		var d time.Duration
+		if b.PausedUntil != 0 {
+			if d = time.Unix(0, b.PausedUntil).Sub(now); d <= 0 {
+				b.PausedUntil = 0
+			}
+		}
+		need := min(n, b.Capacity)
+		if b.Tokens < need && b.Rate > 0 {
+			d = max(d, time.Duration((need-b.Tokens)/b.Rate*float64(time.Second)))
+		}
+		if d > 0 {
+			wait = max(wait, d)
+			blocked = append(blocked, spec.Key)
+		}
+	}
+	if wait > 0 {
+		return wait, blocked
+	}
+	for _, spec := range specs {
+		b := t[spec.Key]
+		b.Tokens -= min(n, b.Capacity)
+	}
+	return 0, nil
+}
+
+func (t bucketTable) pause(key string, until time.Time) {
+	b, ok := t[key]
+	if !ok {
+		b = &bucketRecord{}
+		t[key] = b
+	}
+	if u := until.UnixNano(); u > b.PausedUntil {
+		b.PausedUntil = u
+	}
+}
+
+func (t bucketTable) clamp(now time.Time, remaining float64, specs []BucketSpec) {
+	for _, spec := range specs {
+		if b := t.refill(spec, now); b.Tokens > remaining {
+			b.Tokens = remaining
+		}
+	}
+}
+
+func (t bucketTable) snapshot(now time.Time) []BucketState {
+	states := make([]BucketState, 0, len(t))
+	for key, b := range t {
+		state := BucketState{Key: key, Tokens: b.Tokens, Capacity: b.Capacity}
+		if !b.Filled {
+			state.Tokens = b.Capacity
+		} else if elapsed := now.Sub(time.Unix(0, b.Last)).Seconds(); elapsed > 0 {
+			state.Tokens = min(b.Capacity, b.Tokens+elapsed*b.Rate)
+		}
+		if b.PausedUntil > now.UnixNano() {
+			state.PausedUntil = time.Unix(0, b.PausedUntil)
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// MemoryLimiterBackend keeps buckets in process memory. It is the default
+// backend of NewRateLimiter.
+type MemoryLimiterBackend struct {
+	mu      sync.Mutex
+	buckets bucketTable
+}
+
+func NewMemoryLimiterBackend() *MemoryLimiterBackend {
+	return &MemoryLimiterBackend{buckets: make(bucketTable)}
+}
+
+func (m *MemoryLimiterBackend) Take(now time.Time, n float64, buckets []BucketSpec) (time.Duration, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wait, blocked := m.buckets.take(now, n, buckets)
+	return wait, blocked, nil
+}
+
+func (m *MemoryLimiterBackend) Pause(key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets.pause(key, until)
+	return nil
+}
+
+func (m *MemoryLimiterBackend) Clamp(now time.Time, remaining float64, buckets []BucketSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets.clamp(now, remaining, buckets)
+	return nil
+}
+
+func (m *MemoryLimiterBackend) Snapshot(now time.Time) ([]BucketState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buckets.snapshot(now), nil
+}
@@ -0,0 +1,31 @@
+//go:build !unix
+
+package bingxgo
+
+import (
+	"errors"
+	"time"
+)
+
+// FileLimiterBackend is only available on Unix systems.
+type FileLimiterBackend struct{}
+
+func NewFileLimiterBackend(path string) (*FileLimiterBackend, error) {
+	return nil, errors.New("file limiter backend requires a unix system")
+}
+
+func (b *FileLimiterBackend) Take(time.Time, float64, []BucketSpec) (time.Duration, []string, error) {
+	return 0, nil, errors.ErrUnsupported
+}
+
+func (b *FileLimiterBackend) Pause(string, time.Time) error {
+	return errors.ErrUnsupported
+}
+
+func (b *FileLimiterBackend) Clamp(time.Time, float64, []BucketSpec) error {
+	return errors.ErrUnsupported
+}
+
+func (b *FileLimiterBackend) Snapshot(time.Time) ([]BucketState, error) {
+	return nil, errors.ErrUnsupported
+}
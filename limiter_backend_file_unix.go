@@ -0,0 +1,101 @@
+//go:build unix
+
+package bingxgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// FileLimiterBackend shares token buckets between processes on one host
+// through a state file guarded by flock(2). Every process using the same
+// API key should point at the same path and configure the same limits.
+type FileLimiterBackend struct {
+	path string
+}
+
+// NewFileLimiterBackend creates the state file at path if it does not exist.
+func NewFileLimiterBackend(path string) (*FileLimiterBackend, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening limiter state: %w", err)
+	}
+	f.Close()
+	return &FileLimiterBackend{path: path}, nil
+}
+
+// update runs fn on the bucket table under an exclusive lock and persists
+// the result.
+func (b *FileLimiterBackend) update(fn func(bucketTable)) error {
+	f, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening limiter state: %w", err)
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("error locking limiter state: %w", err)
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("error reading limiter state: %w", err)
+	}
+	table := make(bucketTable)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &table); err != nil {
+			// A corrupt state only costs the current budget, start afresh.
+			table = make(bucketTable)
+		}
+	}
+
+	fn(table)
+
+	data, err = json.Marshal(table)
+	if err != nil {
+		return fmt.Errorf("error encoding limiter state: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("error writing limiter state: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("error writing limiter state: %w", err)
+	}
+	return nil
+}
+
+func (b *FileLimiterBackend) Take(now time.Time, n float64, buckets []BucketSpec) (time.Duration, []string, error) {
+	var (
+		wait    time.Duration
+		blocked []string
+	)
+	err := b.update(func(t bucketTable) {
+		wait, blocked = t.take(now, n, buckets)
+	})
+	return wait, blocked, err
+}
+
+func (b *FileLimiterBackend) Pause(key string, until time.Time) error {
+	return b.update(func(t bucketTable) {
+		t.pause(key, until)
+	})
+}
+
+func (b *FileLimiterBackend) Clamp(now time.Time, remaining float64, buckets []BucketSpec) error {
+	return b.update(func(t bucketTable) {
+		t.clamp(now, remaining, buckets)
+	})
+}
+
+func (b *FileLimiterBackend) Snapshot(now time.Time) ([]BucketState, error) {
+	var states []BucketState
+	err := b.update(func(t bucketTable) {
+		states = t.snapshot(now)
+	})
+	return states, err
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package bingxgo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLimiterBackendSharesBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	now := time.Unix(1700000000, 0)
+
+	newLimiter := func() *RateLimiter {
+		backend, err := NewFileLimiterBackend(path)
+		assert.NoError(t, err)
+		r := NewRateLimiter(WithLimiterBackend(backend))
+		r.now = func() time.Time { return now }
+		r.SetLimit(GroupOrder, Limit{Requests: 2, Interval: time.Second, PerAccount: true})
+		return r
+	}
+	first, second := newLimiter(), newLimiter()
+
+	req := limitRequest{method: "POST", endpoint: "/openApi/spot/v1/trade/order", account: "a"}
+	assertAcquire(t, first, req, 0)
+	assertAcquire(t, second, req, 0)
+	assertAcquire(t, first, req, 500*time.Millisecond)
+
+	second.Add("/openApi/spot/v1/trade/order", 2*time.Second)
+	now = now.Add(time.Second)
+	assertAcquire(t, first, req, time.Second)
+
+	var paused bool
+	for _, s := range first.Snapshot() {
+		paused = paused || !s.PausedUntil.IsZero()
+	}
+	assert.True(t, paused)
+}
@@ -0,0 +1,352 @@
+package bingxgo
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// resyncTimeout bounds each snapshot request of a resync.
+	resyncTimeout = 30 * time.Second
+
+	resyncInitialBackoff = 500 * time.Millisecond
+	resyncMaxBackoff     = 30 * time.Second
+)
+
+// vwapPlaces is the number of fractional digits of VWAP results.
+const vwapPlaces = 12
+
+// PriceLevel is the total quantity resting at one price.
+type PriceLevel struct {
+	Price    Decimal
+	Quantity Decimal
+}
+
+// SnapshotFunc fetches a REST depth snapshot, e.g. SpotClient.OrderBookCtx.
+type SnapshotFunc func(ctx context.Context, symbol string, limit int) (*OrderBook, error)
+
+// LocalOrderBook maintains an order book from the incremental depth stream.
+// It is seeded from a REST snapshot so it can be queried right away, then
+// follows the stream's snapshot and updates. When an update's sequence number
+// does not follow the previous one, the book is reseeded from the REST
+// snapshot, retrying with backoff until it succeeds, and the next update
+// becomes the new base. The stream stays subscribed, as it may be shared with
+// other subscriptions. Without a snapshot function the book waits for the
+// stream's next snapshot instead. All methods are safe for concurrent use.
+type LocalOrderBook struct {
+	// SnapshotLimit is the number of levels requested from the REST snapshot.
+	SnapshotLimit int
+
+	symbol   string
+	stream   MarketStream
+	snapshot SnapshotFunc
+
+	mu           sync.RWMutex
+	bids         []PriceLevel // best (highest) first
+	asks         []PriceLevel // best (lowest) first
+	lastUpdateID int64
+	// sequenced is set once lastUpdateID is a valid base for gap checks.
+	sequenced bool
+	// resyncing drops updates until the stream sends a new snapshot.
+	resyncing bool
+	sub       *Subscription
+	closed    bool
+	done      chan struct{}
+	onResync  []func(error)
+}
+
+// NewLocalOrderBook creates a book for symbol fed by stream. snapshot may be
+// nil, the book then stays empty until the stream's first snapshot.
+func NewLocalOrderBook(symbol string, stream MarketStream, snapshot SnapshotFunc) *LocalOrderBook {
+	return &LocalOrderBook{
+		SnapshotLimit: 100,
+		symbol:        symbol,
+		stream:        stream,
+		snapshot:      snapshot,
+		done:          make(chan struct{}),
+	}
+}
+
+func (b *LocalOrderBook) Start() error {
+	return b.StartCtx(context.Background())
+}
+
+// StartCtx seeds the book from the REST snapshot and subscribes to the
+// incremental depth stream.
+func (b *LocalOrderBook) StartCtx(ctx context.Context) error {
+	if err := b.seed(ctx); err != nil {
+		return err
+	}
+	sub, err := b.stream.SubscribeIncrDepthCtx(ctx, b.symbol, b.apply)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.sub = sub
+	b.mu.Unlock()
+	return nil
+}
+
+// Close unsubscribes from the depth stream. The book keeps its last state.
+func (b *LocalOrderBook) Close() error {
+	b.mu.Lock()
+	sub := b.sub
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+	if sub == nil {
+		return nil
+	}
+	return sub.Unsubscribe()
+}
+
+// OnResync registers fn to be called after every resync attempt, with the
+// error that made it fail if any.
+func (b *LocalOrderBook) OnResync(fn func(error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onResync = append(b.onResync, fn)
+}
+
+// seed replaces the book with the REST snapshot.
+func (b *LocalOrderBook) seed(ctx context.Context) error {
+	if b.snapshot == nil {
+		return nil
+	}
+	book, err := b.snapshot(ctx, b.symbol, b.SnapshotLimit)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replace(*book)
+	// The REST snapshot has no sequence number, the next update becomes the
+	// base.
+	b.sequenced = false
+	return nil
+}
+
+func (b *LocalOrderBook) apply(event DepthUpdateEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case event.Action == "all":
+		b.replace(event.OrderBook)
+		b.lastUpdateID = event.LastUpdateID
+		b.sequenced = true
+		b.resyncing = false
+		return
+	case b.resyncing:
+		return
+	case !b.sequenced:
+	case event.LastUpdateID <= b.lastUpdateID:
+		// Already applied.
+		return
+	case event.LastUpdateID != b.lastUpdateID+1:
+		b.resyncing = true
+		if b.snapshot != nil {
+			go b.resync()
+		}
+		return
+	}
+
+	for _, level := range event.Bids {
+		if len(level) >= 2 {
+			b.bids = setLevel(b.bids, level[0], level[1], true)
+		}
+	}
+	for _, level := range event.Asks {
+		if len(level) >= 2 {
+			b.asks = setLevel(b.asks, level[0], level[1], false)
+		}
+	}
+	b.lastUpdateID = event.LastUpdateID
+	b.sequenced = true
+}
+
+// resync reseeds the book until it succeeds, the stream sends a snapshot or
+// the book is closed. It runs on its own goroutine, as the snapshot request
+// would otherwise stall the stream.
+func (b *LocalOrderBook) resync() {
+	for attempt := 1; ; attempt++ {
+		b.mu.RLock()
+		stopped := b.closed || !b.resyncing
+		b.mu.RUnlock()
+		if stopped {
+			return
+		}
+
+		err := b.reseed()
+		b.mu.RLock()
+		handlers := b.onResync
+		b.mu.RUnlock()
+		for _, fn := range handlers {
+			fn(err)
+		}
+		if err == nil {
+			return
+		}
+
+		timer := time.NewTimer(exponentialBackoff(resyncInitialBackoff, resyncMaxBackoff, 2, 0.2, attempt))
+		select {
+		case <-timer.C:
+		case <-b.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// reseed replaces the book with the REST snapshot unless the stream sent one
+// meanwhile. Updates are applied again from the next one on.
+func (b *LocalOrderBook) reseed() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+	defer cancel()
+	book, err := b.snapshot(ctx, b.symbol, b.SnapshotLimit)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.resyncing {
+		b.replace(*book)
+		b.sequenced = false
+		b.resyncing = false
+	}
+	return nil
+}
+
+// replace sets the book to the levels of book. The caller holds b.mu.
+func (b *LocalOrderBook) replace(book OrderBook) {
+	b.bids, b.asks = b.bids[:0], b.asks[:0]
+	for _, level := range book.Bids {
+		if len(level) >= 2 {
+			b.bids = setLevel(b.bids, level[0], level[1], true)
+		}
+	}
+	for _, level := range book.Asks {
+		if len(level) >= 2 {
+			b.asks = setLevel(b.asks, level[0], level[1], false)
+		}
+	}
+}
+
+// setLevel sets the quantity at price in levels sorted best first, removing
+// the level when qty is zero.
+func setLevel(levels []PriceLevel, price, qty Decimal, descending bool) []PriceLevel {
+	i := sort.Search(len(levels), func(i int) bool {
+		c := levels[i].Price.Cmp(price)
+		if descending {
+			return c <= 0
+		}
+		return c >= 0
+	})
+	found := i < len(levels) && levels[i].Price.Equal(price)
+	switch {
+	case qty.IsZero() && found:
+		return slices.Delete(levels, i, i+1)
+	case qty.IsZero():
+		return levels
+	case found:
+		levels[i].Quantity = qty
+		return levels
+	default:
+		return slices.Insert(levels, i, PriceLevel{Price: price, Quantity: qty})
+	}
+}
+
+func (b *LocalOrderBook) BestBid() (PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.bids[0], true
+}
+
+func (b *LocalOrderBook) BestAsk() (PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.asks[0], true
+}
+
+// Spread returns the best ask minus the best bid, false if a side is empty.
+func (b *LocalOrderBook) Spread() (Decimal, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 || len(b.asks) == 0 {
+		return Decimal{}, false
+	}
+	return b.asks[0].Price.Sub(b.bids[0].Price), true
+}
+
+// Depth returns copies of the best n levels of each side, all levels when n
+// is not positive.
+func (b *LocalOrderBook) Depth(n int) (bids, asks []PriceLevel) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return topLevels(b.bids, n), topLevels(b.asks, n)
+}
+
+func topLevels(levels []PriceLevel, n int) []PriceLevel {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return slices.Clone(levels[:n])
+}
+
+// VWAP returns the average price of filling size against the book, walking
+// the asks for "BUY" and the bids for "SELL". It returns false when the book
+// is too thin to fill size.
+func (b *LocalOrderBook) VWAP(side string, size Decimal) (Decimal, bool) {
+	if size.Sign() <= 0 {
+		return Decimal{}, false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := b.bids
+	if strings.EqualFold(side, "BUY") {
+		levels = b.asks
+	}
+
+	remaining, cost := size, Decimal{}
+	for _, level := range levels {
+		take := level.Quantity
+		if take.GreaterThan(remaining) {
+			take = remaining
+		}
+		cost = cost.Add(take.Mul(level.Price))
+		remaining = remaining.Sub(take)
+		if remaining.IsZero() {
+			return cost.Div(size, vwapPlaces), true
+		}
+	}
+	return Decimal{}, false
+}
+
+// LastUpdateID returns the sequence number of the last applied update.
+func (b *LocalOrderBook) LastUpdateID() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastUpdateID
+}
+
+// Synced reports whether the book follows the stream without a known gap.
+func (b *LocalOrderBook) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sequenced && !b.resyncing
+}
@@ -0,0 +1,119 @@
+package bingxgo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func levels(pairs ...string) [][]Decimal {
+	var out [][]Decimal
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, []Decimal{MustParseDecimal(pairs[i]), MustParseDecimal(pairs[i+1])})
+	}
+	return out
+}
+
+func TestLocalOrderBookQueries(t *testing.T) {
+	b := NewLocalOrderBook("BTC-USDT", MarketStream{}, nil)
+	b.apply(DepthUpdateEvent{Action: "all", LastUpdateID: 1, OrderBook: OrderBook{
+		Bids: levels("99", "1", "100", "2", "98", "5"),
+		Asks: levels("102", "1", "101", "1", "103", "4"),
+	}})
+	b.apply(DepthUpdateEvent{Action: "update", LastUpdateID: 2, OrderBook: OrderBook{
+		Bids: levels("100", "0", "99.5", "3"),
+		Asks: levels("101", "0.5"),
+	}})
+
+	bid, ok := b.BestBid()
+	require.True(t, ok)
+	assert.Equal(t, "99.5", bid.Price.String())
+	ask, _ := b.BestAsk()
+	assert.Equal(t, "0.5", ask.Quantity.String())
+	spread, _ := b.Spread()
+	assert.Equal(t, "1.5", spread.String())
+
+	bids, asks := b.Depth(2)
+	assert.Equal(t, []string{"99.5", "99"}, []string{bids[0].Price.String(), bids[1].Price.String()})
+	assert.Len(t, asks, 2)
+
+	// 0.5 at 101 and 1 at 102 average to 101.666...
+	vwap, ok := b.VWAP("BUY", MustParseDecimal("1.5"))
+	require.True(t, ok)
+	assert.Equal(t, "101.666666666667", vwap.Round(12).String())
+	vwap, ok = b.VWAP("SELL", MustParseDecimal("3"))
+	require.True(t, ok)
+	assert.Equal(t, "99.5", vwap.String())
+	_, ok = b.VWAP("BUY", MustParseDecimal("100"))
+	assert.False(t, ok)
+
+	// Updates already applied are ignored.
+	b.apply(DepthUpdateEvent{Action: "update", LastUpdateID: 2, OrderBook: OrderBook{Bids: levels("99.5", "0")}})
+	bid, _ = b.BestBid()
+	assert.Equal(t, "99.5", bid.Price.String())
+	assert.True(t, b.Synced())
+}
+
+func TestLocalOrderBookResyncsOnGap(t *testing.T) {
+	push := make(chan struct{})
+	requests := make(chan string, 4)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req map[string]string
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 0})
+			requests <- req["reqType"]
+			if req["reqType"] != "sub" {
+				continue
+			}
+			send := func(data string) {
+				writeGzip(conn, []byte(`{"code":0,"dataType":"BTC-USDT@incrDepth","data":`+data+`}`))
+			}
+			send(`{"action":"all","lastUpdateId":1,"bids":[["100","1"]],"asks":[["101","1"]]}`)
+			send(`{"action":"update","lastUpdateId":2,"bids":[["100","2"]],"asks":[]}`)
+			// Update 3 is lost.
+			send(`{"action":"update","lastUpdateId":4,"bids":[["100","4"]],"asks":[]}`)
+			<-push
+			send(`{"action":"update","lastUpdateId":11,"bids":[["100","5"]],"asks":[]}`)
+		}
+	})
+	ws := NewWebsocketClient(url)
+	defer ws.Close()
+
+	var snapshots atomic.Int32
+	snapshot := func(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
+		if snapshots.Add(1) == 2 {
+			return nil, errors.New("snapshot unavailable")
+		}
+		return &OrderBook{Bids: levels("100", "9"), Asks: levels("101", "9")}, nil
+	}
+	book := NewLocalOrderBook("BTC-USDT", NewSpotMarketStream(ws), snapshot)
+	resynced := make(chan error, 2)
+	book.OnResync(func(err error) { resynced <- err })
+	require.NoError(t, book.Start())
+	defer book.Close()
+	assert.Equal(t, "sub", receive(t, requests))
+
+	// The failed snapshot is retried.
+	assert.Error(t, receive(t, resynced))
+	assert.NoError(t, receive(t, resynced))
+	assert.Equal(t, int32(3), snapshots.Load())
+	bid, _ := book.BestBid()
+	assert.Equal(t, "9", bid.Quantity.String())
+
+	// The stream stayed subscribed and its next update is the new base.
+	close(push)
+	assert.Eventually(t, func() bool { return book.LastUpdateID() == 11 }, time.Second, 10*time.Millisecond)
+	bid, _ = book.BestBid()
+	assert.Equal(t, "5", bid.Quantity.String())
+	assert.True(t, book.Synced())
+	assert.Empty(t, requests)
+}
@@ -0,0 +1,161 @@
+package bingxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Logger is the structured logger used by Client. *slog.Logger satisfies it,
+// and args follow the slog key/value convention.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) DebugContext(context.Context, string, ...any) {}
+func (nopLogger) InfoContext(context.Context, string, ...any)  {}
+func (nopLogger) WarnContext(context.Context, string, ...any)  {}
+func (nopLogger) ErrorContext(context.Context, string, ...any) {}
+
+func newDebugLogger() Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// stderrDebugLogger backs the deprecated Client.Debug field.
+var stderrDebugLogger = sync.OnceValue(newDebugLogger)
+
+// log returns the logger of c, honouring the deprecated Debug field when no
+// logger is configured.
+func (c *Client) log() Logger {
+	_, nop := c.logger.(nopLogger)
+	if c.Debug && (nop || c.logger == nil) {
+		return stderrDebugLogger()
+	}
+	if c.logger == nil {
+		return nopLogger{}
+	}
+	return c.logger
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveParams are lower-cased substrings of parameter and header names
+// whose values must never reach a log.
+var sensitiveParams = []string{"signature", "secret", "apikey", "api-key", "listenkey", "password", "passphrase"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveParams {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactParams(params map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if isSensitive(k) {
+			v = redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func redactQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redacted
+	}
+	for k := range values {
+		if isSensitive(k) {
+			values[k] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
+
+// redactURL redacts the sensitive query parameters of rawURL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redactQuery(u.RawQuery)
+	}
+	return u.String()
+}
+
+// redactURLError rebuilds the *url.Error returned by http.Client, which quotes
+// the full signed URL, with its query redacted.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: redactURL(urlErr.URL), Err: urlErr.Err}
+}
+
+// redactBody redacts the values of sensitive keys in a JSON body, such as the
+// listenKey returned by CreateListenKey. Other bodies are returned as is.
+func redactBody(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || !redactValue(v) {
+		return string(body)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactValue redacts v in place and reports whether anything was redacted.
+func redactValue(v interface{}) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if isSensitive(k) {
+				v[k] = redacted
+				found = true
+			} else if redactValue(child) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if redactValue(child) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+func redactHeader(header http.Header) http.Header {
+	out := header.Clone()
+	for k := range out {
+		if isSensitive(k) {
+			out[k] = []string{redacted}
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package bingxgo
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":100001,"msg":"signature verification failed"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient("my-api-key", "my-secret", WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := c.sendRequest("POST", "/test", map[string]interface{}{"symbol": "BTC-USDT", "listenKey": "my-listen-key"})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	out := buf.String()
+	assert.Contains(t, out, `"endpoint":"/test"`)
+	assert.Contains(t, out, `"code":100001`)
+	assert.Contains(t, out, "BTC-USDT")
+	assert.NotContains(t, out, "my-api-key")
+	assert.NotContains(t, out, "my-listen-key")
+	assert.Contains(t, out, "signature=%5BREDACTED%5D")
+}
+
+func TestLoggerRedactsTransportErrorsAndBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"listenKey":"my-listen-key"}`))
+	}))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient("my-api-key", "my-secret", WithBaseURL(server.URL), WithLogger(logger))
+
+	key, err := c.CreateListenKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "my-listen-key", key)
+	assert.NotContains(t, buf.String(), "my-listen-key")
+
+	// The transport error quotes the request URL.
+	server.Close()
+	buf.Reset()
+	_, err = c.sendRequest("GET", "/test", map[string]interface{}{"symbol": "BTC-USDT"})
+	assert.Error(t, err)
+	signature := regexp.MustCompile(`signature=[0-9a-f]{64}`)
+	assert.NotRegexp(t, signature, err.Error())
+	assert.Contains(t, err.Error(), "signature=%5BREDACTED%5D")
+	assert.NotRegexp(t, signature, buf.String())
+}
+
+func TestDeprecatedDebugField(t *testing.T) {
+	c := NewClient("key", "secret")
+	assert.Equal(t, nopLogger{}, c.log())
+	c.Debug = true
+	assert.Equal(t, stderrDebugLogger(), c.log())
+
+	// A configured logger wins over the field.
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c = NewClient("key", "secret", WithLogger(logger))
+	c.Debug = true
+	assert.Equal(t, Logger(logger), c.log())
+}
@@ -1,7 +1,7 @@
 package bingxgo
 
 import (
-	"encoding/json"
+	"context"
 	"strconv"
 )
 
@@ -9,19 +9,23 @@ type MarketClient struct {
 	client *Client
 }
 
-func (c *MarketClient) GetKlines(symbol string, interval string, limit int) ([]Kline, error) {
-	params := map[string]interface{}{
-		"symbol":   symbol,
-		"interval": interval,
-		"limit":    strconv.Itoa(limit),
-	}
+func NewMarketClient(client *Client) MarketClient {
+	return MarketClient{client: client}
+}
 
-	resp, err := c.client.sendRequest("GET", "/openApi/swap/v3/quote/klines", params)
-	if err != nil {
-		return nil, err
-	}
+func (c *MarketClient) GetKlines(symbol string, interval string, limit int) ([]Kline, error) {
+	return c.GetKlinesCtx(context.Background(), symbol, interval, limit)
+}
 
-	var klines []Kline
-	err = json.Unmarshal(resp, &klines)
-	return klines, err
+func (c *MarketClient) GetKlinesCtx(ctx context.Context, symbol string, interval string, limit int) ([]Kline, error) {
+	return do[[]Kline](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/swap/v3/quote/klines",
+		params: map[string]interface{}{
+			"symbol":   symbol,
+			"interval": interval,
+			"limit":    strconv.Itoa(limit),
+		},
+		public: true,
+	})
 }
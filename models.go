@@ -1,6 +1,9 @@
 package bingxgo
 
-import "fmt"
+import (
+	"encoding/json"
+	"net/http"
+)
 
 type BingXResponse[T any] struct {
 	Code     int    `json:"code"`
@@ -11,7 +14,7 @@ type BingXResponse[T any] struct {
 
 func (resp BingXResponse[T]) Error() error {
 	if resp.Code != 0 {
-		return fmt.Errorf("code: %d, msg: %s, debugMsg: %s", resp.Code, resp.Msg, resp.DebugMsg)
+		return &APIError{HTTPStatus: http.StatusOK, Code: resp.Code, Message: resp.Msg, DebugMsg: resp.DebugMsg}
 	}
 	return nil
 }
@@ -20,58 +23,60 @@ type SpotOrderRequest struct {
 	Symbol      string  `json:"symbol"`
 	Side        string  `json:"side"` // BUY, SELL
 	Type        string  `json:"type"` // LIMIT, MARKET
-	Quantity    float64 `json:"quantity"`
-	Price       float64 `json:"price,omitempty"`
+	Quantity    Decimal `json:"quantity"`
+	Price       Decimal `json:"price"`                 // not sent when zero, as for MARKET
 	TimeInForce string  `json:"timeInForce,omitempty"` // GTC, IOC, FOK
+	// NewClientOrderId makes the order safe to retry, see RetryPolicy.
+	NewClientOrderId string `json:"newClientOrderId,omitempty"`
 }
 
 type SpotOrderResponse struct {
-	Symbol              string `json:"symbol"`
-	OrderId             int64  `json:"orderId"`
-	TransactTime        int64  `json:"transactTime"`
-	Price               string `json:"price"`
-	StopPrice           string `json:"stopPrice"`
-	OrigQty             string `json:"origQty"`
-	ExecutedQty         string `json:"executedQty"`
-	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
-	Status              string `json:"status"`
-	Type                string `json:"type"`
-	Side                string `json:"side"`
-	ClientOrderID       string `json:"clientOrderID"`
+	Symbol              string  `json:"symbol"`
+	OrderId             int64   `json:"orderId"`
+	TransactTime        int64   `json:"transactTime"`
+	Price               Decimal `json:"price"`
+	StopPrice           Decimal `json:"stopPrice"`
+	OrigQty             Decimal `json:"origQty"`
+	ExecutedQty         Decimal `json:"executedQty"`
+	CummulativeQuoteQty Decimal `json:"cummulativeQuoteQty"`
+	Status              string  `json:"status"`
+	Type                string  `json:"type"`
+	Side                string  `json:"side"`
+	ClientOrderID       string  `json:"clientOrderID"`
 }
 
 type SpotOrder struct {
 	OrderId     int     `json:"orderId"`
 	Symbol      string  `json:"symbol"`
-	Price       string  `json:"price"`
-	OrigQty     string  `json:"origQty"`
-	ExecutedQty string  `json:"executedQty"`
+	Price       Decimal `json:"price"`
+	OrigQty     Decimal `json:"origQty"`
+	ExecutedQty Decimal `json:"executedQty"`
 	Status      string  `json:"status"`
 	Type        string  `json:"type"`
 	Side        string  `json:"side"`
 	Time        int64   `json:"time"`
-	Fee         float64 `json:"fee"`
-	AvgPrice    float64 `json:"avgPrice"`
+	Fee         Decimal `json:"fee"`
+	AvgPrice    Decimal `json:"avgPrice"`
 }
 
 type SpotBalance struct {
-	Asset  string `json:"asset"`
-	Free   string `json:"free"`
-	Locked string `json:"locked"`
+	Asset  string  `json:"asset"`
+	Free   Decimal `json:"free"`
+	Locked Decimal `json:"locked"`
 }
 
 type Balance struct {
-	Available float64 `json:"available"`
-	Locked    float64 `json:"locked"`
+	Available Decimal `json:"available"`
+	Locked    Decimal `json:"locked"`
 }
 
 type Kline struct {
-	Open   float64 `json:"open"`
-	High   float64 `json:"high"`
-	Low    float64 `json:"low"`
-	Close  float64 `json:"close"`
-	Volume float64 `json:"volume"`
-	Time   string  `json:"time"`
+	Open   Decimal `json:"open"`
+	High   Decimal `json:"high"`
+	Low    Decimal `json:"low"`
+	Close  Decimal `json:"close"`
+	Volume Decimal `json:"volume"`
+	Time   int64   `json:"time"` // open time in milliseconds
 }
 
 type OrderRequest struct {
@@ -79,8 +84,10 @@ type OrderRequest struct {
 	Side         string  `json:"side"`         // BUY, SELL
 	PositionSide string  `json:"positionSide"` // LONG, SHORT
 	Type         string  `json:"type"`         // LIMIT, MARKET
-	Quantity     float64 `json:"quantity"`
-	Price        float64 `json:"price"`
+	Quantity     Decimal `json:"quantity"`
+	Price        Decimal `json:"price"`
+	// ClientOrderID makes the order safe to retry, see RetryPolicy.
+	ClientOrderID string `json:"clientOrderID,omitempty"`
 }
 
 type OrderResponse struct {
@@ -93,10 +100,10 @@ type OrderResponse struct {
 type OrderBook struct {
 	// The timestamp of when the orderbook last changed (in milliseconds)
 	Timestamp int64 `json:"ts,omitempty"`
-	// Asks order depth
-	Asks [][]string `json:"asks"`
-	// Bids order depth
-	Bids [][]string `json:"bids"`
+	// Asks order depth as [price, quantity] pairs
+	Asks [][]Decimal `json:"asks"`
+	// Bids order depth as [price, quantity] pairs
+	Bids [][]Decimal `json:"bids"`
 }
 
 type SymbolInfos struct {
@@ -105,12 +112,12 @@ type SymbolInfos struct {
 
 type SymbolInfo struct {
 	Symbol       string  `json:"symbol"`       // 交易对符号
-	TickSize     float64 `json:"tickSize"`     // 最小价格变动单位
-	StepSize     float64 `json:"stepSize"`     // 最小交易单位
-	MinQty       float64 `json:"minQty"`       // 最小下单量
-	MaxQty       float64 `json:"maxQty"`       // 最大下单量
-	MinNotional  float64 `json:"minNotional"`  // 最小名义价值
-	MaxNotional  float64 `json:"maxNotional"`  // 最大名义价值
+	TickSize     Decimal `json:"tickSize"`     // 最小价格变动单位
+	StepSize     Decimal `json:"stepSize"`     // 最小交易单位
+	MinQty       Decimal `json:"minQty"`       // 最小下单量
+	MaxQty       Decimal `json:"maxQty"`       // 最大下单量
+	MinNotional  Decimal `json:"minNotional"`  // 最小名义价值
+	MaxNotional  Decimal `json:"maxNotional"`  // 最大名义价值
 	Status       int     `json:"status"`       // 状态标识
 	ApiStateBuy  bool    `json:"apiStateBuy"`  // 买入API状态
 	ApiStateSell bool    `json:"apiStateSell"` // 卖出API状态
@@ -125,12 +132,51 @@ type Ticker struct {
 }
 
 type Trade struct {
-	Timestamp int64  `json:"timestamp"`
-	TradeId   string `json:"tradeId"`
-	Price     string `json:"price"`
-	Amount    string `json:"amount"`
-	Type      int    `json:"type"`
-	Volume    string `json:"volume"`
+	Timestamp int64   `json:"timestamp"`
+	TradeId   string  `json:"tradeId"`
+	Price     Decimal `json:"price"`
+	Amount    Decimal `json:"amount"`
+	Type      int     `json:"type"`
+	Volume    Decimal `json:"volume"`
+}
+
+// SpotTrade is a public spot trade from the recent or historical trades.
+type SpotTrade struct {
+	// ID is sent as a number or a string depending on the endpoint.
+	ID         json.Number `json:"id"`
+	Price      Decimal     `json:"price"`
+	Qty        Decimal     `json:"qty"`
+	Time       int64       `json:"time"`
+	BuyerMaker bool        `json:"buyerMaker"`
+}
+
+// Ticker24h holds the statistics of a symbol over the last 24 hours.
+type Ticker24h struct {
+	Symbol      string  `json:"symbol"`
+	OpenPrice   Decimal `json:"openPrice"`
+	HighPrice   Decimal `json:"highPrice"`
+	LowPrice    Decimal `json:"lowPrice"`
+	LastPrice   Decimal `json:"lastPrice"`
+	PriceChange Decimal `json:"priceChange"`
+	// PriceChangePercent is passed through as sent, e.g. "1.52%".
+	PriceChangePercent string  `json:"priceChangePercent"`
+	Volume             Decimal `json:"volume"`
+	QuoteVolume        Decimal `json:"quoteVolume"`
+	BidPrice           Decimal `json:"bidPrice"`
+	BidQty             Decimal `json:"bidQty"`
+	AskPrice           Decimal `json:"askPrice"`
+	AskQty             Decimal `json:"askQty"`
+	OpenTime           int64   `json:"openTime"`
+	CloseTime          int64   `json:"closeTime"`
+}
+
+// BookTicker is the best bid and ask of a symbol.
+type BookTicker struct {
+	Symbol   string  `json:"symbol"`
+	BidPrice Decimal `json:"bidPrice"`
+	BidQty   Decimal `json:"bidVolume"`
+	AskPrice Decimal `json:"askPrice"`
+	AskQty   Decimal `json:"askVolume"`
 }
 
 //	{
@@ -146,16 +192,16 @@ type Trade struct {
 //	    "confirmTimes": "2/2"
 //	  }
 type DepositRecord struct {
-	Amount        string `json:"amount"`
-	Coin          string `json:"coin"`
-	Network       string `json:"network"`
-	Status        int    `json:"status"`
-	Address       string `json:"address"`
-	AddressTag    string `json:"addressTag"`
-	TxId          string `json:"txId"`
-	InsertTime    int64  `json:"insertTime"`
-	UnlockConfirm string `json:"unlockConfirm"`
-	ConfirmTimes  string `json:"confirmTimes"`
+	Amount        Decimal `json:"amount"`
+	Coin          string  `json:"coin"`
+	Network       string  `json:"network"`
+	Status        int     `json:"status"`
+	Address       string  `json:"address"`
+	AddressTag    string  `json:"addressTag"`
+	TxId          string  `json:"txId"`
+	InsertTime    int64   `json:"insertTime"`
+	UnlockConfirm string  `json:"unlockConfirm"`
+	ConfirmTimes  string  `json:"confirmTimes"`
 }
 
 // [
@@ -176,15 +222,132 @@ type DepositRecord struct {
 //
 // ]
 type WithdrawRecord struct {
-	Address        string `json:"address"`
-	Amount         string `json:"amount"`
-	ApplyTime      string `json:"applyTime"`
-	Coin           string `json:"coin"`
-	Id             string `json:"id"`
-	Network        string `json:"network"`
-	TransferType   int    `json:"transferType"`
-	TransactionFee string `json:"transactionFee"`
-	ConfirmNo      int    `json:"confirmNo"`
-	Info           string `json:"info"`
-	TxId           string `json:"txId"`
+	Address        string  `json:"address"`
+	Amount         Decimal `json:"amount"`
+	ApplyTime      string  `json:"applyTime"`
+	Coin           string  `json:"coin"`
+	Id             string  `json:"id"`
+	Network        string  `json:"network"`
+	TransferType   int     `json:"transferType"`
+	TransactionFee Decimal `json:"transactionFee"`
+	ConfirmNo      int     `json:"confirmNo"`
+	Info           string  `json:"info"`
+	TxId           string  `json:"txId"`
+}
+
+// DepthEvent is a depth snapshot pushed by a depth stream.
+type DepthEvent struct {
+	Symbol string
+	OrderBook
+}
+
+// DepthUpdateEvent is pushed by an incremental depth stream. Action is "all"
+// for a full snapshot and "update" for changed levels, where a zero quantity
+// removes the level.
+type DepthUpdateEvent struct {
+	Symbol       string
+	Action       string
+	LastUpdateID int64
+	OrderBook
+}
+
+// TradeEvent is a public trade. Volume holds the base quantity and Amount the
+// quote amount.
+type TradeEvent struct {
+	Symbol     string
+	BuyerMaker bool
+	Trade
+}
+
+type KlineEvent struct {
+	Symbol   string
+	Interval string
+	Kline
+}
+
+// TickerEvent is a rolling 24 hour ticker.
+type TickerEvent struct {
+	Symbol      string
+	EventTime   int64
+	PriceChange Decimal
+	// PriceChangePercent is passed through as sent, BingX may append "%".
+	PriceChangePercent string
+	Open               Decimal
+	High               Decimal
+	Low                Decimal
+	Last               Decimal
+	Volume             Decimal
+	QuoteVolume        Decimal
+	OpenTime           int64
+	CloseTime          int64
+}
+
+type BookTickerEvent struct {
+	Symbol    string
+	UpdateID  int64
+	EventTime int64
+	BidPrice  Decimal
+	BidQty    Decimal
+	AskPrice  Decimal
+	AskQty    Decimal
+}
+
+type MarkPriceEvent struct {
+	Symbol    string
+	EventTime int64
+	MarkPrice Decimal
+}
+
+// OrderUpdateEvent reports a change of one of the account's orders, from the
+// spot executionReport or the swap ORDER_TRADE_UPDATE event.
+type OrderUpdateEvent struct {
+	Symbol        string
+	OrderID       int64
+	ClientOrderID string
+	Side          string
+	// PositionSide is set for swap orders only.
+	PositionSide string
+	Type         string
+	// ExecutionType is the cause of the update, e.g. NEW, TRADE or CANCELED.
+	ExecutionType   string
+	Status          string
+	Price           Decimal
+	Quantity        Decimal
+	AvgPrice        Decimal
+	LastFilledPrice Decimal
+	LastFilledQty   Decimal
+	CumulativeQty   Decimal
+	CumulativeQuote Decimal
+	Commission      Decimal
+	CommissionAsset string
+	RealizedPnL     Decimal
+	EventTime       int64
+	TradeTime       int64
+}
+
+type BalanceUpdate struct {
+	Asset              string  `json:"a"`
+	WalletBalance      Decimal `json:"wb"`
+	CrossWalletBalance Decimal `json:"cw"`
+	BalanceChange      Decimal `json:"bc"`
+}
+
+type PositionUpdate struct {
+	Symbol         string  `json:"s"`
+	PositionSide   string  `json:"ps"`
+	MarginType     string  `json:"mt"`
+	Amount         Decimal `json:"pa"`
+	EntryPrice     Decimal `json:"ep"`
+	UnrealizedPnL  Decimal `json:"up"`
+	IsolatedWallet Decimal `json:"iw"`
+}
+
+// AccountUpdateEvent reports changed balances and, for swap accounts,
+// changed positions.
+type AccountUpdateEvent struct {
+	EventTime int64
+	// Reason is the cause of the update, e.g. ORDER or FUNDING_FEE.
+	Reason    string
+	Balances  []BalanceUpdate
+	Positions []PositionUpdate
 }
@@ -0,0 +1,107 @@
+package bingxgo
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures a Client. Options are applied in order, so an
+// option that tweaks the HTTP client should follow WithHTTPClient.
+type ClientOption func(*Client)
+
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.BaseURL = baseURL
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		httpClient := *c.HTTPClient
+		httpClient.Transport = transport
+		c.HTTPClient = &httpClient
+	}
+}
+
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(c *Client) {
+		transport, ok := c.HTTPClient.Transport.(*http.Transport)
+		if !ok || transport == nil {
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+		transport = transport.Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+
+		httpClient := *c.HTTPClient
+		httpClient.Transport = transport
+		c.HTTPClient = &httpClient
+	}
+}
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		httpClient := *c.HTTPClient
+		httpClient.Timeout = timeout
+		c.HTTPClient = &httpClient
+	}
+}
+
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		if logger == nil {
+			logger = nopLogger{}
+		}
+		c.logger = logger
+	}
+}
+
+// WithDebug logs every request at debug level to stderr. Credentials are
+// redacted; use WithLogger to send the same records elsewhere.
+func WithDebug(debug bool) ClientOption {
+	return func(c *Client) {
+		if debug {
+			c.logger = newDebugLogger()
+		} else {
+			c.logger = nopLogger{}
+		}
+	}
+}
+
+func WithRateLimiter(rateLimiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter = rateLimiter
+	}
+}
+
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithDefaultRecvWindow sets Client.RecvWindow; use WithRecvWindow to
+// override it for a single request.
+func WithDefaultRecvWindow(window time.Duration) ClientOption {
+	return func(c *Client) {
+		c.RecvWindow = window
+	}
+}
+
+func WithTimeSyncInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.TimeSyncInterval = interval
+	}
+}
+
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
@@ -0,0 +1,28 @@
+package bingxgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicRequestsWithoutCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.RawQuery)
+		assert.Empty(t, r.Header.Get("X-BX-APIKEY"))
+		w.Write([]byte(`{"code":0,"data":[{"symbol":"BTC-USDT","trades":[]}]}`))
+	}))
+	defer server.Close()
+
+	spot := NewSpotClient(NewClient("", "", WithBaseURL(server.URL)))
+	tickers, err := spot.GetTickers("")
+	assert.NoError(t, err)
+	assert.Len(t, tickers, 1)
+
+	// A client with credentials keeps them off public endpoints.
+	spot = NewSpotClient(NewClient("my-api-key", "my-secret", WithBaseURL(server.URL)))
+	_, err = spot.GetTickers("")
+	assert.NoError(t, err)
+}
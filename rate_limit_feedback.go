@@ -0,0 +1,145 @@
+package bingxgo
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultThrottleBackoff is the first pause after a rate-limit response
+	// without a Retry-After hint; it doubles on every consecutive hit.
+	defaultThrottleBackoff = time.Second
+	maxThrottleBackoff     = time.Minute
+)
+
+// remainingHeaders carry the server's view of the remaining request budget.
+var remainingHeaders = []string{"X-RateLimit-Remaining", "X-Ratelimit-Remaining-Requests"}
+
+type RateLimitEventKind int
+
+const (
+	// RateLimitThrottled means BingX rejected a request for its rate and
+	// the affected groups are paused until ResumeAt.
+	RateLimitThrottled RateLimitEventKind = iota
+	// RateLimitRecovered means a previously throttled group succeeded again.
+	RateLimitRecovered
+)
+
+type RateLimitEvent struct {
+	Kind       RateLimitEventKind
+	Endpoint   string
+	Groups     []LimitGroup
+	StatusCode int
+	Code       int
+	// RetryAfter is the server's hint, zero when it sent none.
+	RetryAfter time.Duration
+	ResumeAt   time.Time
+}
+
+// OnEvent registers fn to be called, outside the limiter's lock, whenever a
+// group is throttled or recovers. Schedulers can use it to shed low-priority
+// traffic.
+func (r *RateLimiter) OnEvent(fn func(RateLimitEvent)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// observe adapts the limiter to a response: it trusts a lower remaining
+// budget reported by the server and pauses the request's groups after a
+// rate-limit rejection.
+func (r *RateLimiter) observe(req limitRequest, statusCode int, header http.Header, code int) {
+	throttled := statusCode == http.StatusTooManyRequests || errorCodes[code] == ErrRateLimited
+	now := r.now()
+
+	r.mu.Lock()
+	refs, _ := r.resolve(req)
+	r.mu.Unlock()
+
+	if remaining, ok := parseRemaining(header); ok {
+		specs := make([]BucketSpec, 0, len(refs))
+		for _, ref := range refs {
+			specs = append(specs, ref.spec())
+		}
+		_ = r.backend.Clamp(now, remaining, specs)
+	}
+
+	r.mu.Lock()
+	var event *RateLimitEvent
+	if throttled {
+		event = &RateLimitEvent{
+			Kind:       RateLimitThrottled,
+			Endpoint:   req.endpoint,
+			StatusCode: statusCode,
+			Code:       code,
+			RetryAfter: parseRetryAfter(header, now),
+		}
+		for _, ref := range refs {
+			// An HTTP 429 is an IP limit; a BingX code is an account limit.
+			if ref.group == GroupIP && statusCode != http.StatusTooManyRequests {
+				continue
+			}
+			backoff := event.RetryAfter
+			if backoff <= 0 {
+				backoff = min(defaultThrottleBackoff<<r.strikes[ref.key], maxThrottleBackoff)
+			}
+			r.strikes[ref.key]++
+			resumeAt := now.Add(backoff)
+			_ = r.backend.Pause(ref.key, resumeAt)
+			event.Groups = append(event.Groups, ref.group)
+			event.ResumeAt = maxTime(event.ResumeAt, resumeAt)
+		}
+	} else if statusCode == http.StatusOK {
+		for _, ref := range refs {
+			if _, ok := r.strikes[ref.key]; ok {
+				delete(r.strikes, ref.key)
+				if event == nil {
+					event = &RateLimitEvent{Kind: RateLimitRecovered, Endpoint: req.endpoint, StatusCode: statusCode}
+				}
+				event.Groups = append(event.Groups, ref.group)
+			}
+		}
+	}
+	handlers := r.handlers
+	r.mu.Unlock()
+
+	if event != nil {
+		for _, fn := range handlers {
+			fn(*event)
+		}
+	}
+}
+
+func parseRemaining(header http.Header) (float64, bool) {
+	for _, name := range remainingHeaders {
+		if v := header.Get(name); v != "" {
+			if remaining, err := strconv.ParseFloat(v, 64); err == nil && remaining >= 0 {
+				return remaining, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads Retry-After as delay seconds or an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(v, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,179 @@
+package bingxgo
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Priority orders requests competing for the same rate-limit budget. When a
+// budget is exhausted, waiting requests of a higher priority are admitted
+// before lower ones.
+type Priority int
+
+const (
+	PriorityMarketData Priority = iota
+	PriorityQuery
+	PriorityCreate
+	PriorityCancel
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityMarketData:
+		return "market-data"
+	case PriorityQuery:
+		return "query"
+	case PriorityCreate:
+		return "create"
+	case PriorityCancel:
+		return "cancel"
+	default:
+		return "unknown"
+	}
+}
+
+// priorities lists every lane from highest to lowest.
+var priorities = []Priority{PriorityCancel, PriorityCreate, PriorityQuery, PriorityMarketData}
+
+type priorityKey struct{}
+
+// WithPriority overrides the priority derived from the endpoint for requests
+// sent with ctx.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// requestPriority returns the priority set with WithPriority, or the one
+// derived from method and endpoint.
+func requestPriority(ctx context.Context, method, endpoint string) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return classifyPriority(method, endpoint)
+}
+
+// classifyPriority ranks cancellations above order placement, placement above
+// account queries and queries above public market data.
+func classifyPriority(method, endpoint string) Priority {
+	lower := strings.ToLower(endpoint)
+	switch {
+	case strings.Contains(lower, "cancel"),
+		method == http.MethodDelete && strings.Contains(lower, "/trade/"):
+		return PriorityCancel
+	case method != http.MethodGet && strings.Contains(lower, "/trade/"):
+		return PriorityCreate
+	}
+	for _, segment := range marketPathSegments {
+		if strings.Contains(lower, segment) {
+			return PriorityMarketData
+		}
+	}
+	return PriorityQuery
+}
+
+// LaneStats reports the queue of one priority lane.
+type LaneStats struct {
+	Priority Priority
+	// Waiting is the number of requests currently queued in the lane.
+	Waiting int
+	// Admitted counts the requests the lane has let through.
+	Admitted uint64
+	// TotalWait and MaxWait measure the time admitted requests spent queued.
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// AvgWait returns the mean queueing time of admitted requests.
+func (s LaneStats) AvgWait() time.Duration {
+	if s.Admitted == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Admitted)
+}
+
+// waiter is a request queued in the limiter.
+type waiter struct {
+	priority Priority
+	keys     []string
+	// blocked holds the bucket keys that refused the last attempt. Lower
+	// priority requests drawing from them are held back.
+	blocked []string
+}
+
+// LaneStats returns the statistics of every lane, highest priority first.
+func (r *RateLimiter) LaneStats() []LaneStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]LaneStats, 0, len(priorities))
+	for _, p := range priorities {
+		s := r.lanes[p]
+		s.Priority = p
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// enqueue adds w to its lane.
+func (r *RateLimiter) enqueue(w *waiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waiters[w] = struct{}{}
+	s := r.lanes[w.priority]
+	s.Waiting++
+	r.lanes[w.priority] = s
+}
+
+// dequeue removes w, records its wait when admitted and wakes the requests
+// it may have held back.
+func (r *RateLimiter) dequeue(w *waiter, admitted bool, waited time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiters, w)
+	s := r.lanes[w.priority]
+	s.Waiting--
+	if admitted {
+		s.Admitted++
+		s.TotalWait += waited
+		s.MaxWait = max(s.MaxWait, waited)
+	}
+	r.lanes[w.priority] = s
+	r.notifyLocked()
+}
+
+// block records the buckets that refused w and wakes the queue if they changed.
+func (r *RateLimiter) block(w *waiter, keys []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Equal(w.blocked, keys) {
+		w.blocked = keys
+		r.notifyLocked()
+	}
+}
+
+// outranked reports whether a queued request of higher priority is waiting
+// on a bucket w draws from, and returns the channel closed on the next
+// queue change.
+func (r *RateLimiter) outranked(w *waiter) (bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for other := range r.waiters {
+		if other.priority <= w.priority {
+			continue
+		}
+		for _, key := range other.blocked {
+			if slices.Contains(w.keys, key) {
+				return true, r.changed
+			}
+		}
+	}
+	return false, r.changed
+}
+
+func (r *RateLimiter) notifyLocked() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
@@ -0,0 +1,84 @@
+package bingxgo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyPriority(t *testing.T) {
+	tests := []struct {
+		method, endpoint string
+		want             Priority
+	}{
+		{http.MethodPost, "/openApi/spot/v1/trade/cancel", PriorityCancel},
+		{http.MethodDelete, "/openApi/swap/v2/trade/order", PriorityCancel},
+		{http.MethodPost, "/openApi/swap/v2/trade/allOpenOrders/cancel", PriorityCancel},
+		{http.MethodPost, "/openApi/spot/v1/trade/order", PriorityCreate},
+		{http.MethodGet, "/openApi/spot/v1/trade/query", PriorityQuery},
+		{http.MethodGet, "/openApi/spot/v1/account/balance", PriorityQuery},
+		{http.MethodGet, "/openApi/spot/v1/market/depth", PriorityMarketData},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyPriority(tt.method, tt.endpoint), tt.endpoint)
+	}
+
+	ctx := WithPriority(context.Background(), PriorityCancel)
+	assert.Equal(t, PriorityCancel, requestPriority(ctx, http.MethodGet, "/openApi/spot/v1/market/depth"))
+}
+
+func TestRateLimiterAdmitsHigherPriorityFirst(t *testing.T) {
+	r := NewRateLimiter()
+	r.SetLimit(GroupOrder, Limit{Requests: 1, Interval: 100 * time.Millisecond, PerAccount: true})
+
+	create := limitRequest{method: http.MethodPost, endpoint: "/openApi/spot/v1/trade/order", account: "a", priority: PriorityCreate}
+	cancel := limitRequest{method: http.MethodPost, endpoint: "/openApi/spot/v1/trade/cancel", account: "a", priority: PriorityCancel}
+	assert.NoError(t, r.acquire(context.Background(), create))
+
+	admitted := make(chan Priority, 2)
+	go func() {
+		assert.NoError(t, r.acquire(context.Background(), create))
+		admitted <- PriorityCreate
+	}()
+	// The create request queues first and would be admitted first without lanes.
+	time.Sleep(20 * time.Millisecond)
+	go func() {
+		assert.NoError(t, r.acquire(context.Background(), cancel))
+		admitted <- PriorityCancel
+	}()
+
+	assert.Equal(t, PriorityCancel, <-admitted)
+	assert.Equal(t, PriorityCreate, <-admitted)
+
+	stats := r.LaneStats()
+	assert.Equal(t, PriorityCancel, stats[0].Priority)
+	assert.Equal(t, uint64(1), stats[0].Admitted)
+	assert.Equal(t, uint64(2), stats[1].Admitted)
+	for _, s := range stats {
+		assert.Zero(t, s.Waiting)
+	}
+	assert.Greater(t, stats[1].MaxWait, 100*time.Millisecond)
+}
+
+func TestRateLimiterLowerPriorityUsesOtherBuckets(t *testing.T) {
+	r := NewRateLimiter()
+	r.SetLimit(GroupOrder, Limit{Requests: 1, Interval: time.Hour, PerAccount: true})
+
+	create := limitRequest{method: http.MethodPost, endpoint: "/openApi/spot/v1/trade/order", account: "a", priority: PriorityCreate}
+	assert.NoError(t, r.acquire(context.Background(), create))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.acquire(ctx, create)
+	time.Sleep(10 * time.Millisecond)
+
+	// Market data shares only the IP budget, which the queued order does not
+	// wait on.
+	wctx, wcancel := context.WithTimeout(context.Background(), time.Second)
+	defer wcancel()
+	assert.NoError(t, r.WaitCtx(wctx, "/openApi/spot/v1/market/depth"))
+	assert.Equal(t, 1, r.LaneStats()[1].Waiting)
+}
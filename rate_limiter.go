@@ -1,35 +1,327 @@
 package bingxgo
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// LimitGroup names a request budget shared by a set of endpoints.
+type LimitGroup string
+
+const (
+	// GroupIP covers every request sent from this host.
+	GroupIP LimitGroup = "ip"
+	// GroupMarket covers public market data, per IP and endpoint.
+	GroupMarket LimitGroup = "market"
+	// GroupAccount covers every signed request of one account (UID).
+	GroupAccount LimitGroup = "account"
+	// GroupOrder covers order placement and cancellation, per account.
+	GroupOrder LimitGroup = "order"
+	// GroupQuery covers account and order queries, per account and endpoint.
+	GroupQuery LimitGroup = "query"
+)
+
+// Limit allows Requests per Interval, refilled continuously, with up to
+// Burst requests admitted at once.
+type Limit struct {
+	Requests int
+	Interval time.Duration
+	// Burst is the bucket capacity, Requests when zero.
+	Burst int
+	// PerAccount keeps a separate budget for every API key.
+	PerAccount bool
+	// PerEndpoint keeps a separate budget for every endpoint.
+	PerEndpoint bool
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	if l.Interval <= 0 {
+		return 0
+	}
+	return float64(l.Requests) / l.Interval.Seconds()
+}
+
+// EndpointRule assigns an endpoint to limit groups with a request weight.
+type EndpointRule struct {
+	Groups []LimitGroup
+	Weight int
+}
+
+// DefaultLimits approximate BingX's published IP and UID limits.
+func DefaultLimits() map[LimitGroup]Limit {
+	return map[LimitGroup]Limit{
+		GroupIP:      {Requests: 2000, Interval: 10 * time.Second},
+		GroupMarket:  {Requests: 100, Interval: 10 * time.Second, PerEndpoint: true},
+		GroupAccount: {Requests: 1000, Interval: 10 * time.Second, PerAccount: true},
+		GroupOrder:   {Requests: 10, Interval: time.Second, PerAccount: true},
+		GroupQuery:   {Requests: 10, Interval: time.Second, PerAccount: true, PerEndpoint: true},
+	}
+}
+
+// marketPathSegments identify public market data endpoints.
+var marketPathSegments = []string{"/market/", "/quote/", "/ticker/", "/common/", "/server/"}
+
+// classify derives the limit groups of an endpoint without an explicit rule.
+func classify(method, endpoint string) []LimitGroup {
+	for _, segment := range marketPathSegments {
+		if strings.Contains(endpoint, segment) {
+			return []LimitGroup{GroupIP, GroupMarket}
+		}
+	}
+	if method != http.MethodGet && strings.Contains(endpoint, "/trade/") {
+		return []LimitGroup{GroupIP, GroupAccount, GroupOrder}
+	}
+	return []LimitGroup{GroupIP, GroupAccount, GroupQuery}
+}
+
+// BucketSnapshot reports the remaining capacity of one budget.
+type BucketSnapshot struct {
+	Key       string
+	Group     LimitGroup
+	Remaining float64
+	Capacity  float64
+	// PausedUntil is set while the bucket backs off after a rate-limit response.
+	PausedUntil time.Time
+}
+
+// RateLimiter is a token-bucket limiter with per-group, per-account and
+// per-endpoint budgets. A request is admitted only when every bucket it
+// draws from has enough tokens, and then pays its weight to all of them.
+// Bucket state lives in a LimiterBackend, which may be shared between
+// processes. Waiting requests are queued in priority lanes, see Priority.
 type RateLimiter struct {
-	requests map[string]*time.Timer
-	mu       sync.Mutex
+	mu        sync.Mutex
+	backend   LimiterBackend
+	limits    map[LimitGroup]Limit
+	endpoints map[string]EndpointRule
+	strikes   map[string]uint
+	handlers  []func(RateLimitEvent)
+	waiters   map[*waiter]struct{}
+	lanes     map[Priority]LaneStats
+	changed   chan struct{}
+	now       func() time.Time
+}
+
+type RateLimiterOption func(*RateLimiter)
+
+// WithLimiterBackend stores buckets in backend instead of process memory.
+func WithLimiterBackend(backend LimiterBackend) RateLimiterOption {
+	return func(r *RateLimiter) {
+		r.backend = backend
+	}
 }
 
-func NewRateLimiter() *RateLimiter {
-	return &RateLimiter{
-		requests: make(map[string]*time.Timer),
+func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
+	r := &RateLimiter{
+		backend:   NewMemoryLimiterBackend(),
+		limits:    DefaultLimits(),
+		endpoints: make(map[string]EndpointRule),
+		strikes:   make(map[string]uint),
+		waiters:   make(map[*waiter]struct{}),
+		lanes:     make(map[Priority]LaneStats),
+		changed:   make(chan struct{}),
+		now:       time.Now,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-func (r *RateLimiter) Add(endpoint string, duration time.Duration) {
+// SetLimit replaces the budget of group from the next request on.
+func (r *RateLimiter) SetLimit(group LimitGroup, limit Limit) {
 	r.mu.Lock()
-	r.requests[endpoint] = time.AfterFunc(duration, func() {
-		delete(r.requests, endpoint)
-	})
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+	r.limits[group] = limit
+}
+
+// SetEndpoint overrides the groups and weight of endpoint. Groups defaults to
+// the classification derived from the path and Weight to 1.
+func (r *RateLimiter) SetEndpoint(endpoint string, rule EndpointRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints[endpoint] = rule
+}
+
+// Add blocks endpoint for duration, on top of its token budgets.
+func (r *RateLimiter) Add(endpoint string, duration time.Duration) {
+	_ = r.backend.Pause(endpointBucketKey(endpoint), r.now().Add(duration))
 }
 
 func (r *RateLimiter) Wait(endpoint string) {
+	_ = r.WaitCtx(context.Background(), endpoint)
+}
+
+// WaitCtx blocks until endpoint may be called, or returns ctx.Err().
+func (r *RateLimiter) WaitCtx(ctx context.Context, endpoint string) error {
+	return r.acquire(ctx, limitRequest{
+		endpoint: endpoint,
+		priority: requestPriority(ctx, http.MethodGet, endpoint),
+	})
+}
+
+// limitRequest identifies what a request draws from.
+type limitRequest struct {
+	method   string
+	endpoint string
+	account  string
+	priority Priority
+}
+
+type bucketRef struct {
+	key   string
+	group LimitGroup
+	limit Limit
+}
+
+func (ref bucketRef) spec() BucketSpec {
+	return BucketSpec{Key: ref.key, Capacity: ref.limit.capacity(), Rate: ref.limit.rate()}
+}
+
+// acquire queues req in its priority lane until its buckets admit it. While
+// a higher priority request waits on one of the same buckets, req is held
+// back so the tokens go to the higher lane first.
+func (r *RateLimiter) acquire(ctx context.Context, req limitRequest) error {
+	specs, _ := r.specs(req)
+	w := &waiter{priority: req.priority, keys: make([]string, 0, len(specs))}
+	for _, spec := range specs {
+		w.keys = append(w.keys, spec.Key)
+	}
+
+	r.enqueue(w)
+	start := r.now()
+	admitted := false
+	defer func() {
+		r.dequeue(w, admitted, r.now().Sub(start))
+	}()
+
+	for {
+		if held, changed := r.outranked(w); held {
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		wait, blocked, err := r.tryAcquire(req)
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			admitted = true
+			return nil
+		}
+		r.block(w, blocked)
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// tryAcquire takes the request's weight from all its buckets, or returns how
+// long to wait before trying again, and the buckets that refused it, without
+// taking anything.
+func (r *RateLimiter) tryAcquire(req limitRequest) (time.Duration, []string, error) {
+	specs, weight := r.specs(req)
+	return r.backend.Take(r.now(), weight, specs)
+}
+
+// specs returns the buckets req draws from and its weight.
+func (r *RateLimiter) specs(req limitRequest) ([]BucketSpec, float64) {
 	r.mu.Lock()
-	if timer, exists := r.requests[endpoint]; exists {
-		r.mu.Unlock()
-		<-timer.C
-	} else {
-		r.mu.Unlock()
+	refs, weight := r.resolve(req)
+	r.mu.Unlock()
+
+	// The endpoint's own bucket holds no tokens and only carries pauses.
+	specs := []BucketSpec{{Key: endpointBucketKey(req.endpoint)}}
+	for _, ref := range refs {
+		specs = append(specs, ref.spec())
+	}
+	return specs, weight
+}
+
+func endpointBucketKey(endpoint string) string {
+	return "endpoint||" + endpoint
+}
+
+func (r *RateLimiter) resolve(req limitRequest) ([]bucketRef, float64) {
+	rule := r.endpoints[req.endpoint]
+	groups := rule.Groups
+	if len(groups) == 0 {
+		groups = classify(req.method, req.endpoint)
+	}
+	weight := float64(rule.Weight)
+	if weight <= 0 {
+		weight = 1
+	}
+
+	refs := make([]bucketRef, 0, len(groups))
+	for _, group := range groups {
+		limit, ok := r.limits[group]
+		if !ok {
+			continue
+		}
+		key := string(group) + "|"
+		if limit.PerAccount {
+			key += req.account
+		}
+		key += "|"
+		if limit.PerEndpoint {
+			key += req.endpoint
+		}
+		refs = append(refs, bucketRef{key: key, group: group, limit: limit})
+	}
+	return refs, weight
+}
+
+// Snapshot reports the remaining capacity of every bucket in use.
+func (r *RateLimiter) Snapshot() []BucketSnapshot {
+	states, err := r.backend.Snapshot(r.now())
+	if err != nil {
+		return nil
+	}
+
+	snapshots := make([]BucketSnapshot, 0, len(states))
+	for _, state := range states {
+		group := LimitGroup(state.Key[:max(strings.IndexByte(state.Key, '|'), 0)])
+		if group == "endpoint" && state.PausedUntil.IsZero() {
+			continue
+		}
+		snapshots = append(snapshots, BucketSnapshot{
+			Key:         state.Key,
+			Group:       group,
+			Remaining:   state.Tokens,
+			Capacity:    state.Capacity,
+			PausedUntil: state.PausedUntil,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Key < snapshots[j].Key
+	})
+	return snapshots
+}
+
+// accountID identifies an API key in limiter keys without exposing it.
+func accountID(apiKey string) string {
+	if apiKey == "" {
+		return ""
 	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:4])
 }
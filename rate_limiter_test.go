@@ -0,0 +1,126 @@
+package bingxgo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(now *time.Time) *RateLimiter {
+	r := NewRateLimiter()
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func assertAcquire(t *testing.T, r *RateLimiter, req limitRequest, wait time.Duration) {
+	t.Helper()
+	got, _, err := r.tryAcquire(req)
+	assert.NoError(t, err)
+	assert.Equal(t, wait, got)
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newTestLimiter(&now)
+	r.SetLimit(GroupOrder, Limit{Requests: 2, Interval: time.Second, PerAccount: true})
+
+	req := limitRequest{method: "POST", endpoint: "/openApi/spot/v1/trade/order", account: "a"}
+	assertAcquire(t, r, req, 0)
+	assertAcquire(t, r, req, 0)
+	assertAcquire(t, r, req, 500*time.Millisecond)
+
+	// Other accounts have their own order budget.
+	assertAcquire(t, r, limitRequest{method: "POST", endpoint: req.endpoint, account: "b"}, 0)
+
+	now = now.Add(500 * time.Millisecond)
+	assertAcquire(t, r, req, 0)
+}
+
+func TestRateLimiterWeightsAndSnapshot(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newTestLimiter(&now)
+	r.SetLimit(GroupMarket, Limit{Requests: 10, Interval: 10 * time.Second})
+	r.SetEndpoint("/openApi/spot/v1/market/depth", EndpointRule{Weight: 4})
+
+	req := limitRequest{method: "GET", endpoint: "/openApi/spot/v1/market/depth"}
+	assertAcquire(t, r, req, 0)
+	assertAcquire(t, r, req, 0)
+	assertAcquire(t, r, req, 2*time.Second)
+
+	var market BucketSnapshot
+	for _, s := range r.Snapshot() {
+		if s.Group == GroupMarket {
+			market = s
+		}
+	}
+	assert.Equal(t, 2.0, market.Remaining)
+	assert.Equal(t, 10.0, market.Capacity)
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	r := NewRateLimiter()
+	r.Add("/test", time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, r.WaitCtx(ctx, "/test"), context.DeadlineExceeded)
+	assert.NoError(t, r.WaitCtx(context.Background(), "/other"))
+}
+
+func TestRateLimiterBacksOffOnThrottle(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newTestLimiter(&now)
+
+	var events []RateLimitEvent
+	r.OnEvent(func(e RateLimitEvent) { events = append(events, e) })
+
+	req := limitRequest{method: "POST", endpoint: "/openApi/spot/v1/trade/order", account: "a"}
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	r.observe(req, http.StatusTooManyRequests, header, 0)
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, RateLimitThrottled, events[0].Kind)
+	assert.Equal(t, 3*time.Second, events[0].RetryAfter)
+	assert.ElementsMatch(t, []LimitGroup{GroupIP, GroupAccount, GroupOrder}, events[0].Groups)
+	assertAcquire(t, r, req, 3*time.Second)
+
+	// A BingX rate-limit code without a hint pauses only account groups,
+	// with a doubling backoff.
+	now = now.Add(3 * time.Second)
+	r.observe(req, http.StatusOK, http.Header{}, 100410)
+	assert.ElementsMatch(t, []LimitGroup{GroupAccount, GroupOrder}, events[1].Groups)
+	assertAcquire(t, r, req, 2*time.Second)
+	assertAcquire(t, r, limitRequest{method: "GET", endpoint: "/openApi/spot/v1/market/depth"}, 0)
+
+	now = now.Add(2 * time.Second)
+	r.observe(req, http.StatusOK, http.Header{}, 0)
+	assert.Equal(t, RateLimitRecovered, events[2].Kind)
+}
+
+func TestRateLimiterTrustsServerRemaining(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newTestLimiter(&now)
+	r.SetLimit(GroupMarket, Limit{Requests: 10, Interval: 10 * time.Second})
+
+	req := limitRequest{method: "GET", endpoint: "/openApi/spot/v1/market/depth"}
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	r.observe(req, http.StatusOK, header, 0)
+	assertAcquire(t, r, req, time.Second)
+}
+
+func TestBucketRefillIgnoresStaleTime(t *testing.T) {
+	table := bucketTable{}
+	spec := BucketSpec{Key: "k", Capacity: 10, Rate: 1}
+	now := time.Unix(1700000000, 0)
+	table.take(now, 10, []BucketSpec{spec})
+
+	// A clock read before waiting for the backend lock must not rewind Last.
+	table.refill(spec, now.Add(-5*time.Second))
+	b := table.refill(spec, now.Add(5*time.Second))
+	assert.InDelta(t, 5, b.Tokens, 1e-9)
+}
@@ -0,0 +1,63 @@
+package bingxgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// call describes a REST request decoded by do.
+type call struct {
+	method   string
+	endpoint string
+	params   map[string]interface{}
+	// public requests are sent unsigned and without the API key.
+	public bool
+	// key selects a nested field of the envelope's data, e.g. "orders".
+	key string
+}
+
+// do sends r and decodes the data field of the BingX response envelope into
+// T. Every failure is returned as an *APIError carrying the endpoint.
+func do[T any](ctx context.Context, c *Client, r call) (T, error) {
+	var result T
+
+	send := c.sendRequestCtx
+	if r.public {
+		send = c.sendPublicRequestCtx
+	}
+	body, err := send(ctx, r.method, r.endpoint, r.params)
+	if err != nil {
+		return result, err
+	}
+
+	var envelope BingXResponse[json.RawMessage]
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return result, &APIError{Endpoint: r.endpoint, Err: fmt.Errorf("error decoding response: %w", err)}
+	}
+	if envelope.Code != 0 {
+		return result, &APIError{
+			Code:     envelope.Code,
+			Message:  envelope.Msg,
+			DebugMsg: envelope.DebugMsg,
+			Endpoint: r.endpoint,
+		}
+	}
+
+	data := envelope.Data
+	if r.key != "" && len(data) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return result, &APIError{Endpoint: r.endpoint, Err: fmt.Errorf("error decoding response data: %w", err)}
+		}
+		data = fields[r.key]
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return result, nil
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, &APIError{Endpoint: r.endpoint, Err: fmt.Errorf("error decoding response data: %w", err)}
+	}
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package bingxgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeEnvelopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/openApi/swap/v2/trade/order":
+			w.Write([]byte(`{"code":0,"msg":"","data":{"order":{"symbol":"BTC-USDT","orderId":1735950529123455000,"clientOrderID":"my-id"}}}`))
+		case "/openApi/swap/v3/quote/klines":
+			w.Write([]byte(`{"code":0,"msg":"","data":[{"open":"42000.5","close":"42100","high":"42200","low":"41900","volume":"12.5","time":1702717200000}]}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(server.URL))
+
+	trade := NewTradeClient(c)
+	order, err := trade.CreateOrder(OrderRequest{Symbol: "BTC-USDT", Side: "BUY", PositionSide: "LONG", Type: "MARKET", Quantity: DecimalFromInt(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1735950529123455000, order.OrderId)
+	assert.Equal(t, "my-id", order.ClientOrderId)
+
+	market := NewMarketClient(c)
+	klines, err := market.GetKlines("BTC-USDT", "1h", 1)
+	assert.NoError(t, err)
+	assert.Len(t, klines, 1)
+	assert.Equal(t, int64(1702717200000), klines[0].Time)
+}
+
+func TestSpotOrderRequestOmitsZeroPrice(t *testing.T) {
+	market := SpotOrderRequest{Symbol: "BTC-USDT", Side: "BUY", Type: "MARKET", Quantity: MustParseDecimal("0.01")}
+	assert.NotContains(t, market.params(), "price")
+
+	limit := market
+	limit.Type, limit.Price = "LIMIT", MustParseDecimal("37000")
+	assert.Equal(t, limit.Price, limit.params()["price"])
+}
@@ -0,0 +1,133 @@
+package bingxgo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. GET requests are
+// always eligible; requests that create orders are only retried when they
+// carry a client order ID, so a retried request can never open a second order.
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	Multiplier      float64
+	Jitter          float64 // fraction of the backoff randomised, 0..1
+	RetryableStatus []int
+	RetryableCodes  []int
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableCodes: []int{
+			100410, // rate limited
+			100500, // internal system error
+			100503, // server busy
+			80012,  // service unavailable
+		},
+	}
+}
+
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	return exponentialBackoff(p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter, attempt)
+}
+
+// exponentialBackoff returns the delay before the given attempt, starting at
+// initial and growing by multiplier up to maxBackoff, randomised by jitter.
+func exponentialBackoff(initial, maxBackoff time.Duration, multiplier, jitter float64, attempt int) time.Duration {
+	delay := float64(initial)
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if maxBackoff > 0 && delay > float64(maxBackoff) {
+			delay = float64(maxBackoff)
+			break
+		}
+	}
+	if jitter > 0 {
+		delay *= 1 - jitter + 2*jitter*rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+func (p *RetryPolicy) shouldRetry(apiErr *APIError) bool {
+	if errors.Is(apiErr.Err, context.Canceled) || errors.Is(apiErr.Err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr.HTTPStatus == 0 {
+		// Only a failed exchange may succeed when repeated, hook and
+		// encoding errors never reached BingX and would fail again.
+		var transportErr *transportError
+		return errors.As(apiErr.Err, &transportErr)
+	}
+	if apiErr.Code != 0 && slices.Contains(p.RetryableCodes, apiErr.Code) {
+		return true
+	}
+	return slices.Contains(p.RetryableStatus, apiErr.HTTPStatus)
+}
+
+// transportError marks a failure to send a request or read its response,
+// where the request may not have reached BingX.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// clientOrderIDParams are the parameter names BingX uses for caller-assigned
+// order IDs, which make order placement idempotent on the exchange side.
+var clientOrderIDParams = []string{"newClientOrderId", "clientOrderID", "clientOrderId"}
+
+func isIdempotent(method string, params map[string]interface{}) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+	for _, key := range clientOrderIDParams {
+		if id, ok := params[key].(string); ok && id != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
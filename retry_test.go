@@ -0,0 +1,81 @@
+package bingxgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient("key", "secret", WithBaseURL(server.URL))
+	c.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		RetryableStatus: []int{http.StatusServiceUnavailable},
+	})
+	return c
+}
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	c := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"code":0,"data":{}}`))
+	})
+
+	_, err := c.sendRequest("GET", "/test", map[string]interface{}{"symbol": "BTC-USDT"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+	_, err = c.sendRequest("POST", "/test", map[string]interface{}{"symbol": "BTC-USDT", "newClientOrderId": "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetrySkipsOrdersWithoutClientID(t *testing.T) {
+	var calls atomic.Int32
+	c := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.sendRequest("POST", "/test", map[string]interface{}{"symbol": "BTC-USDT"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	calls.Store(0)
+	_, err = c.sendRequest("GET", "/test", map[string]interface{}{"symbol": "BTC-USDT"})
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 3, apiErr.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.HTTPStatus)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestContextCancelStopsInFlightRequest(t *testing.T) {
+	var calls atomic.Int32
+	c := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.sendRequestCtx(ctx, "GET", "/test", map[string]interface{}{"symbol": "BTC-USDT"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), calls.Load())
+}
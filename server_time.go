@@ -0,0 +1,100 @@
+package bingxgo
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const serverTimeEndpoint = "/openApi/swap/v2/server/time"
+
+type recvWindowKey struct{}
+
+// WithRecvWindow overrides the client's RecvWindow for requests made with ctx.
+func WithRecvWindow(ctx context.Context, window time.Duration) context.Context {
+	return context.WithValue(ctx, recvWindowKey{}, window)
+}
+
+func (c *Client) ServerTime() (time.Time, error) {
+	return c.ServerTimeCtx(context.Background())
+}
+
+func (c *Client) ServerTimeCtx(ctx context.Context) (time.Time, error) {
+	resp, err := c.sendPublicRequestCtx(ctx, "GET", serverTimeEndpoint, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var bingXResponse BingXResponse[struct {
+		ServerTime int64 `json:"serverTime"`
+	}]
+	if err := json.Unmarshal(resp, &bingXResponse); err != nil {
+		return time.Time{}, &APIError{Endpoint: serverTimeEndpoint, Err: err}
+	}
+	return time.UnixMilli(bingXResponse.Data.ServerTime), nil
+}
+
+// SyncTime measures the offset between the local clock and BingX server time.
+// The offset is applied to the timestamp of every signed request.
+func (c *Client) SyncTime(ctx context.Context) error {
+	c.lastTimeSync.Store(time.Now().UnixNano())
+
+	start := time.Now()
+	serverTime, err := c.ServerTimeCtx(ctx)
+	if err != nil {
+		return err
+	}
+	end := time.Now()
+
+	local := start.Add(end.Sub(start) / 2)
+	c.timeOffset.Store(int64(serverTime.Sub(local)))
+	return nil
+}
+
+// TimeOffset returns the last measured difference between server and local time.
+func (c *Client) TimeOffset() time.Duration {
+	return time.Duration(c.timeOffset.Load())
+}
+
+// maybeSyncTime refreshes the time offset once TimeSyncInterval has elapsed.
+func (c *Client) maybeSyncTime(ctx context.Context) {
+	if c.TimeSyncInterval <= 0 {
+		return
+	}
+	last := c.lastTimeSync.Load()
+	if time.Since(time.Unix(0, last)) < c.TimeSyncInterval {
+		return
+	}
+	if !c.lastTimeSync.CompareAndSwap(last, time.Now().UnixNano()) {
+		// Another request is already refreshing the offset.
+		return
+	}
+	if err := c.SyncTime(ctx); err != nil {
+		c.log().WarnContext(ctx, "bingx time sync failed", "error", err)
+	}
+}
+
+func (c *Client) now() time.Time {
+	return time.Now().Add(c.TimeOffset())
+}
+
+func (c *Client) recvWindow(ctx context.Context) time.Duration {
+	if window, ok := ctx.Value(recvWindowKey{}).(time.Duration); ok {
+		return window
+	}
+	return c.RecvWindow
+}
+
+// requestParams returns a copy of params, carrying the effective recvWindow
+// for signed requests.
+func (c *Client) requestParams(ctx context.Context, params map[string]interface{}, signed bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		out[k] = v
+	}
+	if window := c.recvWindow(ctx); signed && window > 0 {
+		out["recvWindow"] = strconv.FormatInt(window.Milliseconds(), 10)
+	}
+	return out
+}
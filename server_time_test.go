@@ -0,0 +1,38 @@
+package bingxgo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampRejectionResyncsClock(t *testing.T) {
+	skew := 5 * time.Second
+	var rejected atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == serverTimeEndpoint {
+			fmt.Fprintf(w, `{"code":0,"data":{"serverTime":%d}}`, time.Now().Add(skew).UnixMilli())
+			return
+		}
+		assert.Equal(t, "2500", r.URL.Query().Get("recvWindow"))
+		if !rejected.Swap(true) {
+			w.Write([]byte(`{"code":100421,"msg":"timestamp mismatch"}`))
+			return
+		}
+		w.Write([]byte(`{"code":0,"data":{"balances":[]}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(server.URL), WithDefaultRecvWindow(5*time.Second))
+	spot := NewSpotClient(c)
+
+	_, err := spot.GetBalanceCtx(WithRecvWindow(context.Background(), 2500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(skew), float64(c.TimeOffset()), float64(time.Second))
+}
@@ -1,9 +1,9 @@
 package bingxgo
 
 import (
+	"context"
 	"encoding/json"
-	"strconv"
-	"time"
+	"fmt"
 )
 
 type SpotClient struct {
@@ -15,193 +15,158 @@ func NewSpotClient(client *Client) SpotClient {
 }
 
 func (c *SpotClient) GetBalance() ([]SpotBalance, error) {
-	endpoint := "/openApi/spot/v1/account/balance"
-	params := map[string]interface{}{
-		"timestamp": time.Now().UnixMilli(),
-	}
+	return c.GetBalanceCtx(context.Background())
+}
 
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+func (c *SpotClient) GetBalanceCtx(ctx context.Context) ([]SpotBalance, error) {
+	return do[[]SpotBalance](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/account/balance",
+		key:      "balances",
+	})
+}
+
+func (c *SpotClient) CreateOrder(order SpotOrderRequest) (*SpotOrderResponse, error) {
+	return c.CreateOrderCtx(context.Background(), order)
+}
 
-	var bingXResponse BingXResponse[map[string][]SpotBalance]
-	err = json.Unmarshal(resp, &bingXResponse)
+func (c *SpotClient) CreateOrderCtx(ctx context.Context, order SpotOrderRequest) (*SpotOrderResponse, error) {
+	resp, err := do[SpotOrderResponse](ctx, c.client, call{
+		method:   "POST",
+		endpoint: "/openApi/spot/v1/trade/order",
+		params:   order.params(),
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data["balances"], err
+	return &resp, nil
 }
 
-func (c *SpotClient) CreateOrder(order SpotOrderRequest) (*SpotOrderResponse, error) {
-	endpoint := "/openApi/spot/v1/trade/order"
+func (o SpotOrderRequest) params() map[string]interface{} {
 	params := map[string]interface{}{
-		"symbol":   order.Symbol,
-		"side":     string(order.Side),
-		"type":     string(order.Type),
-		"quantity": strconv.FormatFloat(order.Quantity, 'f', -1, 64),
-		"price":    strconv.FormatFloat(order.Price, 'f', -1, 64),
+		"symbol":   o.Symbol,
+		"side":     o.Side,
+		"type":     o.Type,
+		"quantity": o.Quantity,
 	}
-
-	resp, err := c.client.sendRequest("POST", endpoint, params)
-	if err != nil {
-		return nil, err
+	if !o.Price.IsZero() {
+		params["price"] = o.Price
 	}
-
-	var bingXResponse BingXResponse[SpotOrderResponse]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
+	if o.TimeInForce != "" {
+		params["timeInForce"] = o.TimeInForce
 	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
+	if o.NewClientOrderId != "" {
+		params["newClientOrderId"] = o.NewClientOrderId
 	}
-	return &bingXResponse.Data, err
+	return params
 }
 
 func (c *SpotClient) CreateBatchOrders(orders []SpotOrderRequest, isSync bool) ([]SpotOrderResponse, error) {
-	endpoint := "/openApi/spot/v1/trade/batchOrders"
-
-	ordersJSON, err := json.Marshal(orders)
-	if err != nil {
-		return nil, err
-	}
-	params := map[string]interface{}{
-		"data": string(ordersJSON),
-		"sync": isSync,
-	}
-
-	resp, err := c.client.sendRequest("POST", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+	return c.CreateBatchOrdersCtx(context.Background(), orders, isSync)
+}
 
-	var bingXResponse BingXResponse[map[string][]SpotOrderResponse]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data["orders"], err
+func (c *SpotClient) CreateBatchOrdersCtx(ctx context.Context, orders []SpotOrderRequest, isSync bool) ([]SpotOrderResponse, error) {
+	data := make([]map[string]interface{}, 0, len(orders))
+	for _, order := range orders {
+		data = append(data, order.params())
+	}
+
+	return do[[]SpotOrderResponse](ctx, c.client, call{
+		method:   "POST",
+		endpoint: "/openApi/spot/v1/trade/batchOrders",
+		params: map[string]interface{}{
+			"data": data,
+			"sync": isSync,
+		},
+		key: "orders",
+	})
 }
 
 func (c *SpotClient) GetOpenOrders(symbol string) ([]SpotOrder, error) {
-	endpoint := "/openApi/spot/v1/trade/openOrders"
-	params := map[string]interface{}{
-		"symbol": symbol,
-	}
-
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+	return c.GetOpenOrdersCtx(context.Background(), symbol)
+}
 
-	var bingXResponse BingXResponse[map[string][]SpotOrder]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data["orders"], err
+func (c *SpotClient) GetOpenOrdersCtx(ctx context.Context, symbol string) ([]SpotOrder, error) {
+	return do[[]SpotOrder](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/trade/openOrders",
+		params: map[string]interface{}{
+			"symbol": symbol,
+		},
+		key: "orders",
+	})
 }
 
 func (c *SpotClient) CancelOrder(symbol string, orderId string) error {
-	endpoint := "/openApi/spot/v1/trade/cancel"
-	params := map[string]interface{}{
-		"symbol":  symbol,
-		"orderId": orderId,
-	}
+	return c.CancelOrderCtx(context.Background(), symbol, orderId)
+}
 
-	resp, err := c.client.sendRequest("POST", endpoint, params)
-	if err != nil {
-		return err
-	}
-	var bingXResponse BingXResponse[any]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return err
-	}
-	return nil
+func (c *SpotClient) CancelOrderCtx(ctx context.Context, symbol string, orderId string) error {
+	_, err := do[json.RawMessage](ctx, c.client, call{
+		method:   "POST",
+		endpoint: "/openApi/spot/v1/trade/cancel",
+		params: map[string]interface{}{
+			"symbol":  symbol,
+			"orderId": orderId,
+		},
+	})
+	return err
 }
 
 func (c *SpotClient) CancelAllOpenOrders(symbol string) error {
-	endpoint := "/openApi/spot/v1/trade/cancelOpenOrders"
-	params := map[string]interface{}{
-		"symbol": symbol,
-	}
+	return c.CancelAllOpenOrdersCtx(context.Background(), symbol)
+}
 
-	resp, err := c.client.sendRequest("POST", endpoint, params)
-	if err != nil {
-		return err
-	}
-	var bingXResponse BingXResponse[any]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return err
-	}
+func (c *SpotClient) CancelAllOpenOrdersCtx(ctx context.Context, symbol string) error {
+	_, err := do[json.RawMessage](ctx, c.client, call{
+		method:   "POST",
+		endpoint: "/openApi/spot/v1/trade/cancelOpenOrders",
+		params: map[string]interface{}{
+			"symbol": symbol,
+		},
+	})
 	return err
 }
 
 func (c *SpotClient) GetOrder(symbol string, orderId string) (*SpotOrder, error) {
-	endpoint := "/openApi/spot/v1/trade/order"
-	params := map[string]interface{}{
-		"symbol":  symbol,
-		"orderId": orderId,
-	}
-
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+	return c.GetOrderCtx(context.Background(), symbol, orderId)
+}
 
-	var bingXResponse BingXResponse[SpotOrder]
-	err = json.Unmarshal(resp, &bingXResponse)
+func (c *SpotClient) GetOrderCtx(ctx context.Context, symbol string, orderId string) (*SpotOrder, error) {
+	resp, err := do[SpotOrder](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/trade/order",
+		params: map[string]interface{}{
+			"symbol":  symbol,
+			"orderId": orderId,
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return &bingXResponse.Data, err
+	return &resp, nil
 }
 
 func (c *SpotClient) HistoryOrders(symbol string) ([]SpotOrder, error) {
-	endpoint := "/openApi/spot/v1/trade/historyOrders"
-	params := map[string]interface{}{
-		"symbol": symbol,
-	}
-
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+	return c.HistoryOrdersCtx(context.Background(), symbol)
+}
 
-	var bingXResponse BingXResponse[map[string][]SpotOrder]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data["orders"], err
+func (c *SpotClient) HistoryOrdersCtx(ctx context.Context, symbol string) ([]SpotOrder, error) {
+	return do[[]SpotOrder](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/trade/historyOrders",
+		params: map[string]interface{}{
+			"symbol": symbol,
+		},
+		key: "orders",
+	})
 }
 
 func (c *SpotClient) OrderBook(symbol string, limit int) (*OrderBook, error) {
-	endpoint := "/openApi/spot/v1/market/depth"
+	return c.OrderBookCtx(context.Background(), symbol, limit)
+}
+
+func (c *SpotClient) OrderBookCtx(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
 	params := map[string]interface{}{
 		"symbol": symbol,
 	}
@@ -209,109 +174,89 @@ func (c *SpotClient) OrderBook(symbol string, limit int) (*OrderBook, error) {
 		params["limit"] = limit
 	}
 
-	resp, err := c.client.sendRequest("GET", endpoint, params)
+	resp, err := do[OrderBook](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/market/depth",
+		params:   params,
+		public:   true,
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	var bingXResponse BingXResponse[OrderBook]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return &bingXResponse.Data, err
+	return &resp, nil
 }
 
 func (c *SpotClient) GetSymbolInfo(symbol string) (*SymbolInfo, error) {
-	endpoint := "/openApi/spot/v1/common/symbols"
-	params := map[string]interface{}{
-		"symbol": symbol,
-	}
-
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
+	return c.GetSymbolInfoCtx(context.Background(), symbol)
+}
 
-	var bingXResponse BingXResponse[SymbolInfos]
-	err = json.Unmarshal(resp, &bingXResponse)
+func (c *SpotClient) GetSymbolInfoCtx(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	endpoint := "/openApi/spot/v1/common/symbols"
+	resp, err := do[SymbolInfos](ctx, c.client, call{
+		method:   "GET",
+		endpoint: endpoint,
+		params: map[string]interface{}{
+			"symbol": symbol,
+		},
+		public: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
+	if len(resp.Symbols) == 0 {
+		return nil, &APIError{Endpoint: endpoint, Err: fmt.Errorf("symbol %s not found", symbol)}
 	}
-	return &bingXResponse.Data.Symbols[0], err
+	return &resp.Symbols[0], nil
 }
 
 func (c *SpotClient) GetTickers(symbol string) ([]Ticker, error) {
-	endpoint := "/openApi/spot/v1/ticker/price"
+	return c.GetTickersCtx(context.Background(), symbol)
+}
+
+func (c *SpotClient) GetTickersCtx(ctx context.Context, symbol string) ([]Ticker, error) {
 	params := map[string]interface{}{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var bingXResponse BingXResponse[[]Ticker]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data, err
+	return do[[]Ticker](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/ticker/price",
+		params:   params,
+		public:   true,
+	})
 }
 
 func (c *SpotClient) GetDepositRecords(symbol string) ([]DepositRecord, error) {
-	endpoint := "/openApi/api/v3/capital/deposit/hisrec"
+	return c.GetDepositRecordsCtx(context.Background(), symbol)
+}
+
+func (c *SpotClient) GetDepositRecordsCtx(ctx context.Context, symbol string) ([]DepositRecord, error) {
 	params := map[string]interface{}{}
 	if symbol != "" {
 		params["coin"] = symbol
 	}
 
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var bingXResponse BingXResponse[[]DepositRecord]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data, err
+	return do[[]DepositRecord](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/api/v3/capital/deposit/hisrec",
+		params:   params,
+	})
 }
 
 func (c *SpotClient) GetWithdrawRecords(symbol string) ([]WithdrawRecord, error) {
-	endpoint := "/openApi/api/v3/capital/withdraw/history"
+	return c.GetWithdrawRecordsCtx(context.Background(), symbol)
+}
+
+func (c *SpotClient) GetWithdrawRecordsCtx(ctx context.Context, symbol string) ([]WithdrawRecord, error) {
 	params := map[string]interface{}{}
 	if symbol != "" {
 		params["coin"] = symbol
 	}
 
-	resp, err := c.client.sendRequest("GET", endpoint, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var bingXResponse BingXResponse[[]WithdrawRecord]
-	err = json.Unmarshal(resp, &bingXResponse)
-	if err != nil {
-		return nil, err
-	}
-	if err := bingXResponse.Error(); err != nil {
-		return nil, err
-	}
-	return bingXResponse.Data, err
+	return do[[]WithdrawRecord](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/api/v3/capital/withdraw/history",
+		params:   params,
+	})
 }
@@ -0,0 +1,235 @@
+package bingxgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const spotKlineEndpoint = "/openApi/spot/v2/market/kline"
+
+func (c *SpotClient) GetKlines(symbol string, interval string, limit int) ([]Kline, error) {
+	return c.GetKlinesCtx(context.Background(), symbol, interval, limit)
+}
+
+// GetKlinesCtx returns the latest klines of interval, e.g. "1min", "15min",
+// "1hour" or "1day", oldest first.
+func (c *SpotClient) GetKlinesCtx(ctx context.Context, symbol string, interval string, limit int) ([]Kline, error) {
+	return c.klines(ctx, klineParams(symbol, interval, limit))
+}
+
+func (c *SpotClient) GetKlinesBetween(symbol string, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	return c.GetKlinesBetweenCtx(context.Background(), symbol, interval, start, end, limit)
+}
+
+// GetKlinesBetweenCtx returns the klines of interval opened between start and
+// end, oldest first.
+func (c *SpotClient) GetKlinesBetweenCtx(ctx context.Context, symbol string, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	params := klineParams(symbol, interval, limit)
+	params["startTime"] = start.UnixMilli()
+	params["endTime"] = end.UnixMilli()
+	return c.klines(ctx, params)
+}
+
+func klineParams(symbol, interval string, limit int) map[string]interface{} {
+	params := map[string]interface{}{
+		"symbol":   symbol,
+		"interval": interval,
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	return params
+}
+
+// klines decodes spot klines, sent as arrays of
+// [openTime, open, high, low, close, volume, closeTime, quoteVolume].
+func (c *SpotClient) klines(ctx context.Context, params map[string]interface{}) ([]Kline, error) {
+	rows, err := do[[][]json.Number](ctx, c.client, call{
+		method:   "GET",
+		endpoint: spotKlineEndpoint,
+		params:   params,
+		public:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	klines := make([]Kline, 0, len(rows))
+	for _, row := range rows {
+		kline, err := parseKlineRow(row)
+		if err != nil {
+			return nil, &APIError{Endpoint: spotKlineEndpoint, Err: fmt.Errorf("error decoding response data: %w", err)}
+		}
+		klines = append(klines, kline)
+	}
+	return klines, nil
+}
+
+func parseKlineRow(row []json.Number) (Kline, error) {
+	if len(row) < 6 {
+		return Kline{}, fmt.Errorf("kline has %d fields, want at least 6", len(row))
+	}
+	openTime, err := row[0].Int64()
+	if err != nil {
+		return Kline{}, err
+	}
+	var values [5]Decimal
+	for i := range values {
+		if values[i], err = ParseDecimal(row[i+1].String()); err != nil {
+			return Kline{}, err
+		}
+	}
+	return Kline{
+		Time:   openTime,
+		Open:   values[0],
+		High:   values[1],
+		Low:    values[2],
+		Close:  values[3],
+		Volume: values[4],
+	}, nil
+}
+
+func (c *SpotClient) GetRecentTrades(symbol string, limit int) ([]SpotTrade, error) {
+	return c.GetRecentTradesCtx(context.Background(), symbol, limit)
+}
+
+// GetRecentTradesCtx returns up to limit of the latest trades, at most 100.
+func (c *SpotClient) GetRecentTradesCtx(ctx context.Context, symbol string, limit int) ([]SpotTrade, error) {
+	params := map[string]interface{}{
+		"symbol": symbol,
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+
+	return do[[]SpotTrade](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/market/trades",
+		params:   params,
+		public:   true,
+	})
+}
+
+func (c *SpotClient) GetHistoricalTrades(symbol string, limit int, fromID string) ([]SpotTrade, error) {
+	return c.GetHistoricalTradesCtx(context.Background(), symbol, limit, fromID)
+}
+
+// GetHistoricalTradesCtx returns up to limit trades, at most 500, starting
+// at the trade fromID. An empty fromID returns the latest trades.
+func (c *SpotClient) GetHistoricalTradesCtx(ctx context.Context, symbol string, limit int, fromID string) ([]SpotTrade, error) {
+	params := map[string]interface{}{
+		"symbol": symbol,
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	if fromID != "" {
+		params["fromId"] = fromID
+	}
+
+	return do[[]SpotTrade](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/market/his/v1/trade",
+		params:   params,
+		public:   true,
+	})
+}
+
+func (c *SpotClient) Get24hTickers(symbol string) ([]Ticker24h, error) {
+	return c.Get24hTickersCtx(context.Background(), symbol)
+}
+
+// Get24hTickersCtx returns the 24 hour statistics of symbol, or of every
+// symbol when symbol is empty.
+func (c *SpotClient) Get24hTickersCtx(ctx context.Context, symbol string) ([]Ticker24h, error) {
+	// The endpoint requires a timestamp although it is not signed.
+	params := map[string]interface{}{
+		"timestamp": c.client.now().UnixMilli(),
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+
+	return do[[]Ticker24h](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/ticker/24hr",
+		params:   params,
+		public:   true,
+	})
+}
+
+func (c *SpotClient) GetBookTicker(symbol string) (*BookTicker, error) {
+	return c.GetBookTickerCtx(context.Background(), symbol)
+}
+
+// GetBookTickerCtx returns the best bid and ask of symbol.
+func (c *SpotClient) GetBookTickerCtx(ctx context.Context, symbol string) (*BookTicker, error) {
+	endpoint := "/openApi/spot/v1/ticker/bookTicker"
+	resp, err := do[[]BookTicker](ctx, c.client, call{
+		method:   "GET",
+		endpoint: endpoint,
+		params: map[string]interface{}{
+			"symbol": symbol,
+		},
+		public: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, &APIError{Endpoint: endpoint, Err: fmt.Errorf("symbol %s not found", symbol)}
+	}
+	return &resp[0], nil
+}
+
+func (c *SpotClient) AggregatedOrderBook(symbol string, limit int, precision int) (*OrderBook, error) {
+	return c.AggregatedOrderBookCtx(context.Background(), symbol, limit, precision)
+}
+
+// AggregatedOrderBookCtx returns the order book with prices merged to a
+// precision level from 0, no merging, to 5, the coarsest.
+func (c *SpotClient) AggregatedOrderBookCtx(ctx context.Context, symbol string, limit int, precision int) (*OrderBook, error) {
+	endpoint := "/openApi/spot/v2/market/depth"
+	if precision < 0 || precision > 5 {
+		return nil, &APIError{Endpoint: endpoint, Err: fmt.Errorf("precision %d out of range 0-5", precision)}
+	}
+	params := map[string]interface{}{
+		"symbol": symbol,
+		"type":   fmt.Sprintf("step%d", precision),
+	}
+	if limit > 0 {
+		params["depth"] = limit
+	}
+
+	resp, err := do[OrderBook](ctx, c.client, call{
+		method:   "GET",
+		endpoint: endpoint,
+		params:   params,
+		public:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *SpotClient) ServerTime() (time.Time, error) {
+	return c.ServerTimeCtx(context.Background())
+}
+
+// ServerTimeCtx returns the time of the spot API servers.
+func (c *SpotClient) ServerTimeCtx(ctx context.Context) (time.Time, error) {
+	resp, err := do[struct {
+		ServerTime int64 `json:"serverTime"`
+	}](ctx, c.client, call{
+		method:   "GET",
+		endpoint: "/openApi/spot/v1/server/time",
+		public:   true,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(resp.ServerTime), nil
+}
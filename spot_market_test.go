@@ -0,0 +1,111 @@
+package bingxgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpotMarketClient serves the body of each path and records the queries.
+func newSpotMarketClient(t *testing.T, bodies map[string]string) (SpotClient, map[string]url.Values) {
+	t.Helper()
+	queries := make(map[string]url.Values)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("X-BX-APIKEY"))
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		queries[r.URL.Path] = r.URL.Query()
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewSpotClient(NewClient("", "", WithBaseURL(server.URL))), queries
+}
+
+func TestSpotKlines(t *testing.T) {
+	spot, queries := newSpotMarketClient(t, map[string]string{
+		spotKlineEndpoint: `{"code":0,"data":[[1700000000000,37000.5,37100,36950,37050.25,12.5,1700000059999,463000],[1700000060000,"37050.25","37060","37000","37010","3.25",1700000119999,"120300"]]}`,
+	})
+
+	klines, err := spot.GetKlines("BTC-USDT", "1min", 2)
+	require.NoError(t, err)
+	require.Len(t, klines, 2)
+	assert.Equal(t, int64(1700000000000), klines[0].Time)
+	assert.Equal(t, []string{"37000.5", "37100", "36950", "37050.25", "12.5"}, klineStrings(klines[0]))
+	assert.Equal(t, "3.25", klines[1].Volume.String())
+	assert.Equal(t, "1min", queries[spotKlineEndpoint].Get("interval"))
+	assert.Equal(t, "2", queries[spotKlineEndpoint].Get("limit"))
+
+	start := time.UnixMilli(1700000000000)
+	_, err = spot.GetKlinesBetween("BTC-USDT", "1min", start, start.Add(time.Hour), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "1700003600000", queries[spotKlineEndpoint].Get("endTime"))
+	assert.False(t, queries[spotKlineEndpoint].Has("limit"))
+}
+
+func TestSpotTrades(t *testing.T) {
+	spot, queries := newSpotMarketClient(t, map[string]string{
+		"/openApi/spot/v1/market/trades": `{"code":0,"data":[{"id":101,"price":37000.5,"qty":0.25,"time":1700000000000,"buyerMaker":true}]}`,
+		"/openApi/market/his/v1/trade":   `{"code":0,"data":[{"id":"99","price":"36999","qty":"1","time":1699999999000,"buyerMaker":false}]}`,
+	})
+
+	trades, err := spot.GetRecentTrades("BTC-USDT", 1)
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "101", trades[0].ID.String())
+	assert.Equal(t, "37000.5", trades[0].Price.String())
+	assert.Equal(t, "0.25", trades[0].Qty.String())
+	assert.True(t, trades[0].BuyerMaker)
+
+	trades, err = spot.GetHistoricalTrades("BTC-USDT", 500, "99")
+	require.NoError(t, err)
+	assert.Equal(t, "99", trades[0].ID.String())
+	assert.Equal(t, "99", queries["/openApi/market/his/v1/trade"].Get("fromId"))
+}
+
+func TestSpotTickers(t *testing.T) {
+	spot, queries := newSpotMarketClient(t, map[string]string{
+		"/openApi/spot/v1/ticker/24hr":       `{"code":0,"data":[{"symbol":"BTC-USDT","openPrice":36500,"highPrice":37200,"lowPrice":36400,"lastPrice":37050,"priceChange":550,"priceChangePercent":"1.51%","volume":1234.5,"quoteVolume":45000000,"openTime":1699913600000,"closeTime":1700000000000,"bidPrice":37049,"bidQty":1.2,"askPrice":37051,"askQty":0.8}]}`,
+		"/openApi/spot/v1/ticker/bookTicker": `{"code":0,"data":[{"eventType":"bookTicker","symbol":"BTC-USDT","bidPrice":"37049","bidVolume":"1.2","askPrice":"37051","askVolume":"0.8"}]}`,
+	})
+
+	tickers, err := spot.Get24hTickers("BTC-USDT")
+	require.NoError(t, err)
+	require.Len(t, tickers, 1)
+	assert.Equal(t, "36500", tickers[0].OpenPrice.String())
+	assert.Equal(t, "1.51%", tickers[0].PriceChangePercent)
+	assert.Equal(t, "1234.5", tickers[0].Volume.String())
+	assert.Equal(t, int64(1700000000000), tickers[0].CloseTime)
+	assert.NotEmpty(t, queries["/openApi/spot/v1/ticker/24hr"].Get("timestamp"))
+
+	book, err := spot.GetBookTicker("BTC-USDT")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", book.BidQty.String())
+	assert.Equal(t, "37051", book.AskPrice.String())
+}
+
+func TestSpotAggregatedOrderBookAndServerTime(t *testing.T) {
+	spot, queries := newSpotMarketClient(t, map[string]string{
+		"/openApi/spot/v2/market/depth": `{"code":0,"data":{"bids":[["37000","5"]],"asks":[["37100","2"]],"ts":1700000000000}}`,
+		"/openApi/spot/v1/server/time":  `{"code":0,"data":{"serverTime":1700000000123}}`,
+	})
+
+	book, err := spot.AggregatedOrderBook("BTC-USDT", 20, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "37000", book.Bids[0][0].String())
+	assert.Equal(t, "step2", queries["/openApi/spot/v2/market/depth"].Get("type"))
+	assert.Equal(t, "20", queries["/openApi/spot/v2/market/depth"].Get("depth"))
+
+	_, err = spot.AggregatedOrderBook("BTC-USDT", 20, 6)
+	assert.Error(t, err)
+
+	serverTime, err := spot.ServerTime()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000123), serverTime.UnixMilli())
+}
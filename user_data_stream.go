@@ -0,0 +1,403 @@
+package bingxgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const userDataStreamEndpoint = "/openApi/user/auth/userDataStream"
+
+// defaultListenKeyKeepAlive is how often a listenKey is extended. Keys expire
+// 60 minutes after their last extension.
+const defaultListenKeyKeepAlive = 30 * time.Minute
+
+// CreateListenKey creates a listenKey for the account's user data stream.
+func (c *Client) CreateListenKey() (string, error) {
+	return c.CreateListenKeyCtx(context.Background())
+}
+
+func (c *Client) CreateListenKeyCtx(ctx context.Context) (string, error) {
+	resp, err := c.sendRequestCtx(ctx, "POST", userDataStreamEndpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// The listenKey endpoints answer without the usual envelope.
+	var result struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", &APIError{Endpoint: userDataStreamEndpoint, Err: err}
+	}
+	if result.ListenKey == "" {
+		return "", &APIError{Endpoint: userDataStreamEndpoint, Err: errors.New("no listenKey in response")}
+	}
+	return result.ListenKey, nil
+}
+
+// ExtendListenKey extends the validity of listenKey by 60 minutes.
+func (c *Client) ExtendListenKey(listenKey string) error {
+	return c.ExtendListenKeyCtx(context.Background(), listenKey)
+}
+
+func (c *Client) ExtendListenKeyCtx(ctx context.Context, listenKey string) error {
+	params := map[string]interface{}{
+		"listenKey": listenKey,
+	}
+	_, err := c.sendRequestCtx(ctx, "PUT", userDataStreamEndpoint, params)
+	return err
+}
+
+func (c *Client) DeleteListenKey(listenKey string) error {
+	return c.DeleteListenKeyCtx(context.Background(), listenKey)
+}
+
+func (c *Client) DeleteListenKeyCtx(ctx context.Context, listenKey string) error {
+	params := map[string]interface{}{
+		"listenKey": listenKey,
+	}
+	_, err := c.sendRequestCtx(ctx, "DELETE", userDataStreamEndpoint, params)
+	return err
+}
+
+// UserDataHandlers receive the events of a UserDataStream. Nil handlers are
+// skipped. Handlers run on the connection's read goroutine.
+type UserDataHandlers struct {
+	OnOrder   func(OrderUpdateEvent)
+	OnAccount func(AccountUpdateEvent)
+	// OnError reports listenKey and connection failures. The stream keeps
+	// retrying after each of them.
+	OnError func(error)
+}
+
+// UserDataStream delivers the account's order, balance and position updates.
+// It creates a listenKey, extends it every KeepAliveInterval, and creates a
+// new key and connection when the key expires or is rejected.
+type UserDataStream struct {
+	// BaseURL is the WebSocket endpoint, SpotMarketWSURL or SwapMarketWSURL.
+	BaseURL           string
+	KeepAliveInterval time.Duration
+
+	client   *Client
+	swap     bool
+	handlers UserDataHandlers
+	opts     []WebsocketOption
+
+	mu        sync.Mutex
+	listenKey string
+	ws        *WebsocketClient
+	expired   chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	started   bool
+	closed    bool
+}
+
+func NewSpotUserDataStream(client *Client, handlers UserDataHandlers, opts ...WebsocketOption) *UserDataStream {
+	return newUserDataStream(client, SpotMarketWSURL, false, handlers, opts)
+}
+
+func NewSwapUserDataStream(client *Client, handlers UserDataHandlers, opts ...WebsocketOption) *UserDataStream {
+	return newUserDataStream(client, SwapMarketWSURL, true, handlers, opts)
+}
+
+func newUserDataStream(client *Client, baseURL string, swap bool, handlers UserDataHandlers, opts []WebsocketOption) *UserDataStream {
+	return &UserDataStream{
+		BaseURL:           baseURL,
+		KeepAliveInterval: defaultListenKeyKeepAlive,
+		client:            client,
+		swap:              swap,
+		handlers:          handlers,
+		opts:              opts,
+		expired:           make(chan struct{}, 1),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+func (s *UserDataStream) Start() error {
+	return s.StartCtx(context.Background())
+}
+
+// StartCtx creates the listenKey, connects and subscribes to the account's
+// events. The stream runs until Close.
+func (s *UserDataStream) StartCtx(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrWebsocketClosed
+	}
+	if s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+	go s.keepAlive()
+	return nil
+}
+
+// ListenKey returns the listenKey in use.
+func (s *UserDataStream) ListenKey() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listenKey
+}
+
+// connect creates a new listenKey and connection and replaces the current
+// ones.
+func (s *UserDataStream) connect(ctx context.Context) error {
+	listenKey, err := s.client.CreateListenKeyCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	ws := NewWebsocketClient(s.BaseURL+"?listenKey="+url.QueryEscape(listenKey), s.opts...)
+	ws.listen("listenKeyExpired", func([]byte) {
+		select {
+		case s.expired <- struct{}{}:
+		default:
+		}
+	})
+	if s.swap {
+		ws.listen("ORDER_TRADE_UPDATE", s.dispatchOrder)
+		ws.listen("ACCOUNT_UPDATE", s.dispatchAccount)
+		err = ws.ConnectCtx(ctx)
+	} else {
+		err = ws.SubscribeCtx(ctx, []string{"spot.executionReport"}, s.dispatchOrder)
+		if err == nil {
+			err = ws.SubscribeCtx(ctx, []string{"ACCOUNT_UPDATE"}, s.dispatchAccount)
+		}
+	}
+	if err != nil {
+		ws.Close()
+		_ = s.client.DeleteListenKeyCtx(ctx, listenKey)
+		return err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ws.Close()
+		_ = s.client.DeleteListenKeyCtx(ctx, listenKey)
+		return ErrWebsocketClosed
+	}
+	old, oldKey := s.ws, s.listenKey
+	s.ws, s.listenKey = ws, listenKey
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+		_ = s.client.DeleteListenKeyCtx(ctx, oldKey)
+	}
+	return nil
+}
+
+// keepAlive extends the listenKey on every tick and reconnects with a new
+// key when it expires, is rejected or the connection gives up.
+func (s *UserDataStream) keepAlive() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		ws, listenKey := s.ws, s.listenKey
+		s.mu.Unlock()
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			err := s.client.ExtendListenKeyCtx(context.Background(), listenKey)
+			if err == nil {
+				continue
+			}
+			s.reportError(err)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatus == 0 {
+				// A transport failure says nothing about the key, try
+				// again on the next tick. An answer rejecting it, with or
+				// without a BingX code, needs a new key.
+				continue
+			}
+		case <-s.expired:
+		case <-ws.Done():
+			if err := ws.Err(); err != nil {
+				s.reportError(err)
+			}
+		}
+		s.reconnect()
+	}
+}
+
+// reconnect replaces the listenKey and connection, retrying with backoff
+// until it succeeds or the stream is closed.
+func (s *UserDataStream) reconnect() {
+	for attempt := 1; ; attempt++ {
+		err := s.connect(context.Background())
+		if err == nil || errors.Is(err, ErrWebsocketClosed) {
+			return
+		}
+		s.reportError(err)
+
+		timer := time.NewTimer(exponentialBackoff(time.Second, time.Minute, 2, 0.2, attempt))
+		select {
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *UserDataStream) reportError(err error) {
+	if s.handlers.OnError != nil {
+		s.handlers.OnError(err)
+	}
+}
+
+// Close stops the stream and deletes its listenKey.
+func (s *UserDataStream) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	started := s.started
+	ws, listenKey := s.ws, s.listenKey
+	s.mu.Unlock()
+
+	close(s.stop)
+	if started {
+		<-s.done
+	}
+	if ws == nil {
+		return nil
+	}
+	ws.Close()
+	return s.client.DeleteListenKey(listenKey)
+}
+
+// userEvent returns the event of msg, which is wrapped in a data field on
+// spot and sent bare on swap.
+func userEvent(msg []byte) json.RawMessage {
+	var m wsMessage
+	if err := json.Unmarshal(msg, &m); err == nil && len(m.Data) > 0 {
+		return m.Data
+	}
+	return msg
+}
+
+// wsOrderUpdate decodes the order fields of both markets. Fields whose names
+// differ only in case are all declared, so none is matched to another.
+type wsOrderUpdate struct {
+	wsHeader
+	Symbol          string          `json:"s"`
+	OrderID         int64           `json:"i"`
+	ClientOrderID   string          `json:"c"`
+	Side            string          `json:"S"`
+	Type            string          `json:"o"`
+	Quantity        Decimal         `json:"q"`
+	Price           Decimal         `json:"p"`
+	AvgPrice        Decimal         `json:"ap"`
+	ExecutionType   string          `json:"x"`
+	Status          string          `json:"X"`
+	LastFilledQty   Decimal         `json:"l"`
+	LastFilledPrice Decimal         `json:"L"`
+	CumulativeQty   Decimal         `json:"z"`
+	CumulativeQuote Decimal         `json:"Z"`
+	Commission      Decimal         `json:"n"`
+	CommissionAsset string          `json:"N"`
+	TradeTime       int64           `json:"T"`
+	PositionSide    string          `json:"ps"`
+	RealizedPnL     Decimal         `json:"rp"`
+	TradeID         json.RawMessage `json:"t"`
+	OrigClientID    json.RawMessage `json:"C"`
+	OrderTime       json.RawMessage `json:"O"`
+	StopPrice       json.RawMessage `json:"P"`
+	QuoteQty        json.RawMessage `json:"Q"`
+	Ignore          json.RawMessage `json:"I"`
+	Maker           json.RawMessage `json:"m"`
+	BestMatch       json.RawMessage `json:"M"`
+}
+
+func (s *UserDataStream) dispatchOrder(msg []byte) {
+	if s.handlers.OnOrder == nil {
+		return
+	}
+
+	data := userEvent(msg)
+	var o wsOrderUpdate
+	if s.swap {
+		var raw struct {
+			wsHeader
+			Order wsOrderUpdate `json:"o"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return
+		}
+		o, o.wsHeader = raw.Order, raw.wsHeader
+	} else if err := json.Unmarshal(data, &o); err != nil {
+		return
+	}
+
+	s.handlers.OnOrder(OrderUpdateEvent{
+		Symbol:          o.Symbol,
+		OrderID:         o.OrderID,
+		ClientOrderID:   o.ClientOrderID,
+		Side:            o.Side,
+		PositionSide:    o.PositionSide,
+		Type:            o.Type,
+		ExecutionType:   o.ExecutionType,
+		Status:          o.Status,
+		Price:           o.Price,
+		Quantity:        o.Quantity,
+		AvgPrice:        o.AvgPrice,
+		LastFilledPrice: o.LastFilledPrice,
+		LastFilledQty:   o.LastFilledQty,
+		CumulativeQty:   o.CumulativeQty,
+		CumulativeQuote: o.CumulativeQuote,
+		Commission:      o.Commission,
+		CommissionAsset: o.CommissionAsset,
+		RealizedPnL:     o.RealizedPnL,
+		EventTime:       o.Time,
+		TradeTime:       o.TradeTime,
+	})
+}
+
+func (s *UserDataStream) dispatchAccount(msg []byte) {
+	if s.handlers.OnAccount == nil {
+		return
+	}
+
+	var raw struct {
+		wsHeader
+		Account struct {
+			Reason    string           `json:"m"`
+			Balances  []BalanceUpdate  `json:"B"`
+			Positions []PositionUpdate `json:"P"`
+		} `json:"a"`
+	}
+	if err := json.Unmarshal(userEvent(msg), &raw); err != nil {
+		return
+	}
+	s.handlers.OnAccount(AccountUpdateEvent{
+		EventTime: raw.Time,
+		Reason:    raw.Account.Reason,
+		Balances:  raw.Account.Balances,
+		Positions: raw.Account.Positions,
+	})
+}
@@ -0,0 +1,185 @@
+package bingxgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenKeyServer issues numbered listenKeys and records every call.
+type listenKeyServer struct {
+	mu    sync.Mutex
+	calls []string
+	// unknown is a listenKey answered with 404 on PUT.
+	unknown string
+}
+
+func (s *listenKeyServer) setUnknown(listenKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unknown = listenKey
+}
+
+func (s *listenKeyServer) isUnknown(listenKey string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return listenKey == s.unknown
+}
+
+func (s *listenKeyServer) record(call string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+	return len(s.calls)
+}
+
+func (s *listenKeyServer) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func newListenKeyClient(t *testing.T) (*Client, *listenKeyServer) {
+	t.Helper()
+	keys := &listenKeyServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, userDataStreamEndpoint, r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("X-BX-APIKEY"))
+		switch r.Method {
+		case http.MethodPost:
+			n := keys.record("create")
+			fmt.Fprintf(w, `{"listenKey":"lk%d"}`, n)
+		default:
+			listenKey := r.URL.Query().Get("listenKey")
+			keys.record(r.Method + " " + listenKey)
+			if r.Method == http.MethodPut && keys.isUnknown(listenKey) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewClient("key", "secret", WithBaseURL(server.URL)), keys
+}
+
+func TestSwapUserDataStream(t *testing.T) {
+	client, keys := newListenKeyClient(t)
+
+	var expiredSent sync.Map
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		writeGzip(conn, []byte(`{"e":"ORDER_TRADE_UPDATE","E":1700000000001,"o":{"s":"BTC-USDT","c":"my-1","i":42,"S":"BUY","o":"LIMIT","q":"0.5","p":"37000","ap":"0","x":"NEW","X":"NEW","N":"USDT","n":"0","T":1700000000000,"ps":"LONG","rp":"0","z":"0"}}`))
+		writeGzip(conn, []byte(`{"e":"ACCOUNT_UPDATE","E":1700000000002,"a":{"m":"ORDER","B":[{"a":"USDT","wb":"100.5","cw":"100.5","bc":"-1"}],"P":[{"s":"BTC-USDT","pa":"0.5","ep":"37000","up":"1.2","mt":"isolated","iw":"10","ps":"LONG"}]}}`))
+		if _, loaded := expiredSent.LoadOrStore("lk1", true); !loaded {
+			writeGzip(conn, []byte(`{"e":"listenKeyExpired","E":1700000000003,"listenKey":"lk1"}`))
+		}
+		conn.ReadMessage()
+	})
+
+	orders := make(chan OrderUpdateEvent, 4)
+	accounts := make(chan AccountUpdateEvent, 4)
+	stream := NewSwapUserDataStream(client, UserDataHandlers{
+		OnOrder:   func(e OrderUpdateEvent) { orders <- e },
+		OnAccount: func(e AccountUpdateEvent) { accounts <- e },
+	})
+	stream.BaseURL = url
+	require.NoError(t, stream.Start())
+
+	order := receive(t, orders)
+	assert.Equal(t, int64(42), order.OrderID)
+	assert.Equal(t, "my-1", order.ClientOrderID)
+	assert.Equal(t, "LIMIT", order.Type)
+	assert.Equal(t, "NEW", order.Status)
+	assert.Equal(t, "LONG", order.PositionSide)
+	assert.Equal(t, "0.5", order.Quantity.String())
+	assert.Equal(t, int64(1700000000001), order.EventTime)
+
+	account := receive(t, accounts)
+	assert.Equal(t, "ORDER", account.Reason)
+	assert.Equal(t, "100.5", account.Balances[0].WalletBalance.String())
+	assert.Equal(t, "37000", account.Positions[0].EntryPrice.String())
+
+	// The expiry notice makes the stream create a new key and connection,
+	// which delivers the events again.
+	receive(t, orders)
+	assert.Eventually(t, func() bool { return stream.ListenKey() == "lk2" }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, stream.Close())
+	assert.Equal(t, []string{"create", "create", "DELETE lk1", "DELETE lk2"}, keys.Calls())
+}
+
+func TestSpotUserDataStreamKeepAlive(t *testing.T) {
+	client, keys := newListenKeyClient(t)
+
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for i := 0; i < 2; i++ {
+			req := readSubscription(t, conn)
+			if req == nil {
+				return
+			}
+		}
+		writeGzip(conn, []byte(`{"code":0,"dataType":"spot.executionReport","data":{"e":"executionReport","E":1,"s":"BTC-USDT","S":"SELL","o":"MARKET","q":"1","p":"0","x":"TRADE","X":"FILLED","i":7,"l":"1","z":"1","L":"37000","n":"0.01","N":"USDT","T":2,"t":99,"Z":"37000","c":"c-1","C":"","O":1,"P":"0","Q":"0","I":1,"m":false,"M":true}}`))
+		conn.ReadMessage()
+	})
+
+	orders := make(chan OrderUpdateEvent, 1)
+	stream := NewSpotUserDataStream(client, UserDataHandlers{
+		OnOrder: func(e OrderUpdateEvent) { orders <- e },
+	})
+	stream.BaseURL = url
+	stream.KeepAliveInterval = 20 * time.Millisecond
+	require.NoError(t, stream.Start())
+	defer stream.Close()
+
+	order := receive(t, orders)
+	assert.Equal(t, int64(7), order.OrderID)
+	assert.Equal(t, "FILLED", order.Status)
+	assert.Equal(t, "37000", order.LastFilledPrice.String())
+	assert.Equal(t, "1", order.LastFilledQty.String())
+	assert.Equal(t, "0", order.Price.String())
+
+	assert.Eventually(t, func() bool {
+		for _, call := range keys.Calls() {
+			if call == "PUT lk1" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUserDataStreamReplacesRejectedKey(t *testing.T) {
+	client, keys := newListenKeyClient(t)
+	keys.setUnknown("lk1")
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for readSubscription(t, conn) != nil {
+		}
+	})
+
+	errs := make(chan error, 10)
+	stream := NewSpotUserDataStream(client, UserDataHandlers{
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	stream.BaseURL = url
+	stream.KeepAliveInterval = 20 * time.Millisecond
+	require.NoError(t, stream.Start())
+	defer stream.Close()
+
+	// A 404 without a BingX code still means the key is gone.
+	assert.Equal(t, http.StatusNotFound, receive(t, errs).(*APIError).HTTPStatus)
+	// Keys are numbered by call, the third call creates lk3.
+	assert.Eventually(t, func() bool { return stream.ListenKey() == "lk3" }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"create", "PUT lk1", "create"}, keys.Calls()[:3])
+}
@@ -1,27 +1,682 @@
 package bingxgo
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	SpotMarketWSURL = "wss://open-api-ws.bingx.com/market"
+	SwapMarketWSURL = "wss://open-api-swap.bingx.com/swap-market"
+)
+
+// defaultRequestTimeout bounds how long a request waits for the server's
+// answer when its context has no deadline.
+const defaultRequestTimeout = 10 * time.Second
+
+// writeTimeout bounds every write, so a stalled peer cannot block the read
+// goroutine answering heartbeats or Close.
+const writeTimeout = 10 * time.Second
+
+// defaultHeartbeatTimeout is how long a connection may stay silent before it
+// is considered dead. BingX pings every 5 seconds.
+const defaultHeartbeatTimeout = 30 * time.Second
+
+var (
+	// ErrWebsocketClosed is returned by calls on a closed WebsocketClient.
+	ErrWebsocketClosed = errors.New("bingx: websocket closed")
+	// ErrConnectionLost is returned by requests whose connection dropped
+	// before the server answered.
+	ErrConnectionLost = errors.New("bingx: websocket connection lost")
+	// ErrStaleConnection reports a connection dropped after missed heartbeats.
+	ErrStaleConnection = errors.New("bingx: websocket heartbeat timeout")
+)
+
+// WebsocketClient is a connection to a BingX market data stream. It
+// decompresses BingX's gzip frames, answers the server's heartbeats and
+// dispatches every message to the handler registered for its dataType.
+// Handlers run on the connection's read goroutine and must not block.
+//
+// When the connection drops or stays silent past the heartbeat timeout, the
+// client reconnects with backoff and replays every active subscription.
 type WebsocketClient struct {
-	// client  *Client
-	// baseURL string
+	baseURL          string
+	dialer           *websocket.Dialer
+	reconnect        *ReconnectPolicy
+	heartbeatTimeout time.Duration
+	requestTimeout   time.Duration
+
+	mu      sync.Mutex
+	sess    *wsSession
+	started bool
+	// connecting is closed when the first dial, made without holding mu,
+	// ends. It is nil when no dial is in flight.
+	connecting chan struct{}
+	// consumers holds the consumers of every subscribed stream.
+	consumers map[string][]*streamConsumer
+	listeners map[string]func([]byte)
+	pending   map[string]chan wsMessage
+	events    []func(WebsocketEvent)
+	done      chan struct{}
+	err       error
+	closed    bool
+
+	writeMu      sync.Mutex
+	nextID       atomic.Uint64
+	nextConsumer atomic.Uint64
+}
+
+// wsSession is one connection of a WebsocketClient.
+type wsSession struct {
 	conn *websocket.Conn
+	// done is closed when the connection's reader exits.
+	done chan struct{}
+}
+
+type WebsocketOption func(*WebsocketClient)
+
+// WithDialer replaces the dialer used to open connections, e.g. to set a
+// proxy or TLS configuration.
+func WithDialer(dialer *websocket.Dialer) WebsocketOption {
+	return func(c *WebsocketClient) {
+		c.dialer = dialer
+	}
+}
+
+// WithRequestTimeout sets how long requests wait for the server's answer when
+// their context has no deadline. The default is 10 seconds.
+func WithRequestTimeout(d time.Duration) WebsocketOption {
+	return func(c *WebsocketClient) {
+		c.requestTimeout = d
+	}
+}
+
+// NewWebsocketClient creates a client for baseURL, usually SpotMarketWSURL or
+// SwapMarketWSURL. The connection is opened by Connect or the first Subscribe.
+func NewWebsocketClient(baseURL string, opts ...WebsocketOption) *WebsocketClient {
+	c := &WebsocketClient{
+		baseURL:          baseURL,
+		dialer:           websocket.DefaultDialer,
+		reconnect:        DefaultReconnectPolicy(),
+		heartbeatTimeout: defaultHeartbeatTimeout,
+		requestTimeout:   defaultRequestTimeout,
+		consumers:        make(map[string][]*streamConsumer),
+		listeners:        make(map[string]func([]byte)),
+		pending:          make(map[string]chan wsMessage),
+		done:             make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// wsRequest is a request answered by the message carrying the same ID.
+type wsRequest struct {
+	ID       string      `json:"id"`
+	ReqType  string      `json:"reqType"`
+	DataType string      `json:"dataType,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
 }
 
-func (c *WebsocketClient) Subscribe(streams []string, handler func([]byte)) error {
-	msg := struct {
-		Method string   `json:"method"`
-		Params []string `json:"params"`
-		ID     int64    `json:"id"`
-	}{
-		Method: "SUBSCRIBE",
-		Params: streams,
-		ID:     time.Now().UnixNano(),
+// wsMessage covers every message shape sent by BingX: subscription answers,
+// pushed data, events pushed without subscription and JSON heartbeats.
+type wsMessage struct {
+	ID        string          `json:"id"`
+	Code      int             `json:"code"`
+	Msg       string          `json:"msg"`
+	DataType  string          `json:"dataType"`
+	Data      json.RawMessage `json:"data"`
+	Event     string          `json:"e"`
+	EventTime json.RawMessage `json:"E"`
+	Ping      string          `json:"ping"`
+	Time      json.RawMessage `json:"time"`
+}
+
+func (c *WebsocketClient) Connect() error {
+	return c.ConnectCtx(context.Background())
+}
+
+// ConnectCtx dials the server and starts reading. It does nothing once the
+// client has connected, reconnections are handled in the background. Calls
+// made while another one dials wait for its outcome.
+func (c *WebsocketClient) ConnectCtx(ctx context.Context) error {
+	c.mu.Lock()
+	for {
+		if c.closed {
+			c.mu.Unlock()
+			return ErrWebsocketClosed
+		}
+		if c.started {
+			c.mu.Unlock()
+			return nil
+		}
+		if c.connecting == nil {
+			break
+		}
+		wait := c.connecting
+		c.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		c.mu.Lock()
 	}
+	connecting := make(chan struct{})
+	c.connecting = connecting
+	c.mu.Unlock()
+
+	sess, err := c.dial(ctx)
 
-	return c.conn.WriteJSON(msg)
+	c.mu.Lock()
+	c.connecting = nil
+	close(connecting)
+	if err == nil && c.closed {
+		sess.conn.Close()
+		err = ErrWebsocketClosed
+	}
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.sess = sess
+	c.started = true
+	c.mu.Unlock()
+
+	go c.serve(sess)
+	c.emit(WebsocketEvent{Kind: WebsocketConnected})
+	return nil
+}
+
+func (c *WebsocketClient) dial(ctx context.Context) (*wsSession, error) {
+	conn, _, err := c.dialer.DialContext(ctx, c.baseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing %s: %w", c.baseURL, err)
+	}
+	return &wsSession{conn: conn, done: make(chan struct{})}, nil
+}
+
+func (c *WebsocketClient) Subscribe(streams []string, handler func([]byte), opts ...SubscribeOption) error {
+	return c.SubscribeCtx(context.Background(), streams, handler, opts...)
+}
+
+// SubscribeCtx subscribes to every stream, e.g. "BTC-USDT@depth20", and waits
+// for the server to accept them. handler receives the decompressed messages
+// of the streams. A stream may have several consumers: each call adds
+// handler as one more, and the subscription is only sent for the first.
+// Streams added while the client reconnects are sent with the
+// resubscription.
+//
+// handler runs on the connection's read goroutine unless WithBuffer is given.
+func (c *WebsocketClient) SubscribeCtx(ctx context.Context, streams []string, handler func([]byte), opts ...SubscribeOption) error {
+	_, err := c.subscribeConsumer(ctx, streams, handler, opts)
+	return err
+}
+
+// subscribeConsumer subscribes like SubscribeCtx and returns the consumer
+// ID for unsubscribeConsumer.
+func (c *WebsocketClient) subscribeConsumer(ctx context.Context, streams []string, handler func([]byte), opts []SubscribeOption) (uint64, error) {
+	var cfg subscribeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var buf *streamBuffer
+	if cfg.size > 0 {
+		buf = newStreamBuffer(cfg, handler)
+	}
+	return c.subscribe(ctx, streams, handler, buf)
+}
+
+// streamConsumer is one consumer of a stream. Consumers added by the same
+// call share the ID and buffer.
+type streamConsumer struct {
+	id      uint64
+	handler func([]byte)
+	buf     *streamBuffer
+}
+
+// subscribe adds a consumer with handler, or buf when it is not nil, to
+// streams and sends the subscriptions of streams without consumers. On
+// failure every stream of the call loses the consumer again, which stops buf.
+func (c *WebsocketClient) subscribe(ctx context.Context, streams []string, handler func([]byte), buf *streamBuffer) (uint64, error) {
+	if err := c.ConnectCtx(ctx); err != nil {
+		return 0, err
+	}
+	id := c.nextConsumer.Add(1)
+	if buf != nil {
+		// Held until every stream is registered, so a failure stops buf.
+		buf.retain()
+		defer buf.release()
+		go buf.run()
+	}
+
+	for i, stream := range streams {
+		consumer := &streamConsumer{id: id, handler: handler, buf: buf}
+		if buf != nil {
+			buf.retain()
+			consumer.handler = func(data []byte) { buf.push(stream, data) }
+		}
+		c.mu.Lock()
+		first := len(c.consumers[stream]) == 0
+		c.consumers[stream] = append(c.consumers[stream], consumer)
+		sess := c.sess
+		c.mu.Unlock()
+		if !first || sess == nil {
+			continue
+		}
+
+		err := c.request(ctx, sess, "sub", stream)
+		if errors.Is(err, ErrConnectionLost) && c.reconnect != nil {
+			continue
+		}
+		if err != nil {
+			c.removeConsumer(stream, id)
+			_ = c.unsubscribeConsumer(context.WithoutCancel(ctx), streams[:i], id)
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// removeConsumer drops consumer id of stream, reporting whether it existed
+// and was the last one.
+func (c *WebsocketClient) removeConsumer(stream string, id uint64) (found, last bool) {
+	c.mu.Lock()
+	var removed *streamConsumer
+	// The list is replaced, never changed in place, as handle iterates it
+	// without holding c.mu.
+	var rest []*streamConsumer
+	for _, consumer := range c.consumers[stream] {
+		if consumer.id == id && removed == nil {
+			removed = consumer
+			continue
+		}
+		rest = append(rest, consumer)
+	}
+	if removed != nil {
+		if len(rest) == 0 {
+			delete(c.consumers, stream)
+		} else {
+			c.consumers[stream] = rest
+		}
+	}
+	c.mu.Unlock()
+
+	if removed == nil {
+		return false, false
+	}
+	if removed.buf != nil {
+		removed.buf.release()
+	}
+	return true, len(rest) == 0
+}
+
+// remove drops every consumer of stream and reports whether it had any.
+func (c *WebsocketClient) remove(stream string) bool {
+	c.mu.Lock()
+	consumers := c.consumers[stream]
+	delete(c.consumers, stream)
+	c.mu.Unlock()
+	for _, consumer := range consumers {
+		if consumer.buf != nil {
+			consumer.buf.release()
+		}
+	}
+	return len(consumers) > 0
+}
+
+func (c *WebsocketClient) Unsubscribe(streams []string) error {
+	return c.UnsubscribeCtx(context.Background(), streams)
+}
+
+// UnsubscribeCtx removes every consumer of streams and unsubscribes from
+// them. Messages still in flight are dropped. To remove a single consumer,
+// use the Subscription returned by MarketStream.
+func (c *WebsocketClient) UnsubscribeCtx(ctx context.Context, streams []string) error {
+	var firstErr error
+	for _, stream := range streams {
+		if c.remove(stream) {
+			if err := c.unsubscribe(ctx, stream); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// unsubscribeConsumer removes consumer id from streams and unsubscribes from
+// the streams it was the last consumer of.
+func (c *WebsocketClient) unsubscribeConsumer(ctx context.Context, streams []string, id uint64) error {
+	var firstErr error
+	for _, stream := range streams {
+		if found, last := c.removeConsumer(stream, id); found && last {
+			if err := c.unsubscribe(ctx, stream); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// unsubscribe sends the unsubscription of stream.
+func (c *WebsocketClient) unsubscribe(ctx context.Context, stream string) error {
+	c.mu.Lock()
+	sess := c.sess
+	c.mu.Unlock()
+	if sess == nil {
+		return nil
+	}
+	// A lost connection drops the subscription anyway and the
+	// resubscription no longer includes it.
+	if err := c.request(ctx, sess, "unsub", stream); err != nil && !errors.Is(err, ErrConnectionLost) {
+		return err
+	}
+	return nil
+}
+
+// listen registers handler for messages pushed without a dataType whose
+// event type "e" matches event, such as swap account updates. No request is
+// sent to the server.
+func (c *WebsocketClient) listen(event string, handler func([]byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners[event] = handler
+}
+
+func (c *WebsocketClient) Request(reqType string, data interface{}) (json.RawMessage, error) {
+	return c.RequestCtx(context.Background(), reqType, data)
+}
+
+// RequestCtx sends {"id":...,"reqType":reqType,"data":data} and returns the
+// data of the answer carrying the same id. It waits for the request timeout
+// when ctx has no deadline. Errors are *APIError values, a non-zero code in
+// the answer is matched against the BingX error codes like REST errors.
+//
+// Requests are never resent. When the connection drops before the answer,
+// the error wraps ErrConnectionLost.
+//
+// BingX documents no order placement or cancellation commands on its
+// WebSocket endpoints, so no typed commands are built on RequestCtx. Signed
+// payloads can be made with Client.SignParamsCtx.
+func (c *WebsocketClient) RequestCtx(ctx context.Context, reqType string, data interface{}) (json.RawMessage, error) {
+	sess, err := c.session(ctx)
+	if err != nil {
+		return nil, &APIError{Endpoint: reqType, Err: err}
+	}
+	resp, err := c.roundTrip(ctx, sess, wsRequest{ReqType: reqType, Data: data})
+	if err != nil {
+		return nil, &APIError{Endpoint: reqType, Err: err}
+	}
+	if resp.Code != 0 {
+		return nil, &APIError{Code: resp.Code, Message: resp.Msg, Endpoint: reqType}
+	}
+	return resp.Data, nil
+}
+
+// request sends a subscription request on sess and waits for its answer.
+func (c *WebsocketClient) request(ctx context.Context, sess *wsSession, reqType, dataType string) error {
+	resp, err := c.roundTrip(ctx, sess, wsRequest{ReqType: reqType, DataType: dataType})
+	if err != nil {
+		return err
+	}
+	if resp.Code != 0 {
+		return &APIError{Code: resp.Code, Message: resp.Msg, Endpoint: dataType}
+	}
+	return nil
+}
+
+// session connects if needed and returns the current connection. It fails
+// with ErrConnectionLost while the client reconnects.
+func (c *WebsocketClient) session(ctx context.Context) (*wsSession, error) {
+	if err := c.ConnectCtx(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrWebsocketClosed
+	}
+	if c.sess == nil {
+		return nil, ErrConnectionLost
+	}
+	return c.sess, nil
+}
+
+// roundTrip sends req on sess under a new ID and waits for the message
+// answering it. The answer's code is left to the caller.
+func (c *WebsocketClient) roundTrip(ctx context.Context, sess *wsSession, req wsRequest) (wsMessage, error) {
+	if _, ok := ctx.Deadline(); !ok && c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	req.ID = strconv.FormatUint(c.nextID.Add(1), 10)
+	answer := make(chan wsMessage, 1)
+	c.mu.Lock()
+	c.pending[req.ID] = answer
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	if err := c.writeJSON(sess.conn, req); err != nil {
+		return wsMessage{}, err
+	}
+
+	select {
+	case resp := <-answer:
+		return resp, nil
+	case <-c.done:
+		return wsMessage{}, c.closedErr()
+	case <-sess.done:
+		return wsMessage{}, ErrConnectionLost
+	case <-ctx.Done():
+		return wsMessage{}, ctx.Err()
+	}
+}
+
+func (c *WebsocketClient) writeJSON(conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(conn, data)
+}
+
+func (c *WebsocketClient) write(conn *websocket.Conn, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("error writing websocket message: %w", err)
+	}
+	return nil
+}
+
+// serve reads sess until it fails, then reconnects unless the client stopped.
+func (c *WebsocketClient) serve(sess *wsSession) {
+	err := c.readLoop(sess)
+	close(sess.done)
+	sess.conn.Close()
+
+	c.mu.Lock()
+	if c.sess == sess {
+		c.sess = nil
+	}
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return
+	}
+
+	c.emit(WebsocketEvent{Kind: WebsocketDisconnected, Err: err})
+	if c.reconnect == nil {
+		c.stop(err)
+		return
+	}
+	c.redial(err)
+}
+
+func (c *WebsocketClient) readLoop(sess *wsSession) error {
+	for {
+		if c.heartbeatTimeout > 0 {
+			sess.conn.SetReadDeadline(time.Now().Add(c.heartbeatTimeout))
+		}
+		_, data, err := sess.conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return fmt.Errorf("%w: %v", ErrStaleConnection, err)
+			}
+			return err
+		}
+		data, err = decompress(data)
+		if err != nil {
+			continue
+		}
+		c.handle(sess, data)
+	}
+}
+
+// decompress inflates gzip frames and passes other frames through.
+func decompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (c *WebsocketClient) handle(sess *wsSession, data []byte) {
+	if string(data) == "Ping" {
+		_ = c.write(sess.conn, []byte("Pong"))
+		return
+	}
+
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	if msg.Ping != "" {
+		_ = c.writeJSON(sess.conn, map[string]json.RawMessage{
+			"pong": json.RawMessage(strconv.Quote(msg.Ping)),
+			"time": msg.Time,
+		})
+		return
+	}
+
+	c.mu.Lock()
+	ack, isAck := c.pending[msg.ID]
+	consumers := c.consumers[msg.DataType]
+	var listener func([]byte)
+	if msg.DataType == "" && msg.Event != "" {
+		listener = c.listeners[msg.Event]
+	}
+	c.mu.Unlock()
+
+	switch {
+	case isAck && msg.ID != "":
+		select {
+		case ack <- msg:
+		default:
+		}
+	case listener != nil:
+		listener(data)
+	default:
+		for _, consumer := range consumers {
+			consumer.handler(data)
+		}
+	}
+}
+
+// stop ends the client after the connection failed for good.
+func (c *WebsocketClient) stop(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.err = err
+	close(c.done)
+	c.stopBuffers()
+}
+
+// stopBuffers stops the buffers of every subscription. The caller holds c.mu.
+func (c *WebsocketClient) stopBuffers() {
+	for _, consumers := range c.consumers {
+		for _, consumer := range consumers {
+			if consumer.buf != nil {
+				consumer.buf.stop()
+			}
+		}
+	}
+}
+
+// Close closes the connection and stops reconnecting. Done is closed and Err
+// returns nil afterwards.
+func (c *WebsocketClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	sess := c.sess
+	c.sess = nil
+	close(c.done)
+	c.stopBuffers()
+	c.mu.Unlock()
+
+	if sess == nil {
+		return nil
+	}
+	// A write stuck on a stalled peer holds writeMu, skip the close frame
+	// rather than wait for it.
+	if c.writeMu.TryLock() {
+		_ = sess.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+		c.writeMu.Unlock()
+	}
+	return sess.conn.Close()
+}
+
+// Done is closed when the client stops, after Close or when reconnecting
+// failed for good.
+func (c *WebsocketClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that stopped the client, or nil while it runs and
+// after Close.
+func (c *WebsocketClient) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *WebsocketClient) closedErr() error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return ErrWebsocketClosed
 }
@@ -0,0 +1,296 @@
+package bingxgo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultChanBufferSize is the buffer of SubscribeChan without WithBuffer.
+const defaultChanBufferSize = 256
+
+// OverflowPolicy selects what a subscription buffer does with a message that
+// arrives while it is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stalls the connection's reader until there is room. No
+	// message is lost, but every stream of the connection waits.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered message.
+	OverflowDropOldest
+	// OverflowDropNewest discards the arriving message.
+	OverflowDropNewest
+	// OverflowConflate keeps only the latest message of every stream, so a
+	// slow handler sees the current state of each symbol. Unsuitable for
+	// incremental streams such as incrDepth.
+	OverflowConflate
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowConflate:
+		return "conflate"
+	default:
+		return "unknown"
+	}
+}
+
+type subscribeConfig struct {
+	size   int
+	policy OverflowPolicy
+}
+
+type SubscribeOption func(*subscribeConfig)
+
+// WithBuffer delivers the messages of a subscription from its own goroutine
+// through a buffer of size messages, so a slow handler no longer stalls the
+// connection. policy decides what happens when the buffer is full.
+func WithBuffer(size int, policy OverflowPolicy) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.size = size
+		c.policy = policy
+	}
+}
+
+// StreamStats counts the messages of a buffered subscription.
+type StreamStats struct {
+	// Queued is the number of messages waiting for the handler.
+	Queued    int
+	Delivered uint64
+	Dropped   uint64
+}
+
+type bufferedMessage struct {
+	stream string
+	data   []byte
+}
+
+// streamBuffer queues the messages of one subscription and delivers them on
+// its own goroutine. It is shared by the streams of the subscription and
+// stops when the last of them is released.
+type streamBuffer struct {
+	size    int
+	policy  OverflowPolicy
+	deliver func([]byte)
+	// done is called once run returns.
+	done func()
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []bufferedMessage
+	// latest holds the pending message of each queued stream when
+	// conflating, the queue then only orders the streams.
+	latest  map[string][]byte
+	refs    int
+	stopped bool
+	quit    chan struct{}
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func newStreamBuffer(cfg subscribeConfig, deliver func([]byte)) *streamBuffer {
+	b := &streamBuffer{
+		size:    cfg.size,
+		policy:  cfg.policy,
+		deliver: deliver,
+		latest:  make(map[string][]byte),
+		quit:    make(chan struct{}),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *streamBuffer) push(stream string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+
+	if b.policy == OverflowConflate {
+		if _, ok := b.latest[stream]; ok {
+			b.latest[stream] = data
+			b.dropped.Add(1)
+			return
+		}
+		if len(b.queue) >= b.size {
+			delete(b.latest, b.queue[0].stream)
+			b.queue = b.queue[1:]
+			b.dropped.Add(1)
+		}
+		b.latest[stream] = data
+		b.queue = append(b.queue, bufferedMessage{stream: stream})
+		b.cond.Broadcast()
+		return
+	}
+
+	for len(b.queue) >= b.size {
+		switch b.policy {
+		case OverflowDropNewest:
+			b.dropped.Add(1)
+			return
+		case OverflowDropOldest:
+			b.queue = b.queue[1:]
+			b.dropped.Add(1)
+		default:
+			b.cond.Wait()
+			if b.stopped {
+				return
+			}
+		}
+	}
+	b.queue = append(b.queue, bufferedMessage{stream: stream, data: data})
+	b.cond.Broadcast()
+}
+
+// next waits for a message, false once the buffer stopped.
+func (b *streamBuffer) next() ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.queue) == 0 && !b.stopped {
+		b.cond.Wait()
+	}
+	if b.stopped {
+		return nil, false
+	}
+
+	msg := b.queue[0]
+	b.queue[0] = bufferedMessage{}
+	b.queue = b.queue[1:]
+	if b.policy == OverflowConflate {
+		msg.data = b.latest[msg.stream]
+		delete(b.latest, msg.stream)
+	}
+	b.cond.Broadcast()
+	return msg.data, true
+}
+
+func (b *streamBuffer) run() {
+	defer func() {
+		if b.done != nil {
+			b.done()
+		}
+	}()
+	for {
+		data, ok := b.next()
+		if !ok {
+			return
+		}
+		b.deliver(data)
+		b.delivered.Add(1)
+	}
+}
+
+// retain adds a stream to the buffer.
+func (b *streamBuffer) retain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refs++
+}
+
+// release removes a stream from the buffer and stops it after the last one.
+func (b *streamBuffer) release() {
+	b.mu.Lock()
+	b.refs--
+	last := b.refs <= 0
+	b.mu.Unlock()
+	if last {
+		b.stop()
+	}
+}
+
+// stop discards the queued messages and ends run.
+func (b *streamBuffer) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	b.stopped = true
+	b.queue = nil
+	b.latest = nil
+	close(b.quit)
+	b.cond.Broadcast()
+}
+
+func (b *streamBuffer) stats() StreamStats {
+	b.mu.Lock()
+	queued := len(b.queue)
+	b.mu.Unlock()
+	return StreamStats{
+		Queued:    queued,
+		Delivered: b.delivered.Load(),
+		Dropped:   b.dropped.Load(),
+	}
+}
+
+// Stats returns the counters of a stream subscribed with WithBuffer, summed
+// over its buffered consumers, false for other streams.
+func (c *WebsocketClient) Stats(stream string) (StreamStats, bool) {
+	return c.stats(stream, func(*streamConsumer) bool { return true })
+}
+
+// consumerStats returns the counters of consumer id of stream.
+func (c *WebsocketClient) consumerStats(stream string, id uint64) (StreamStats, bool) {
+	return c.stats(stream, func(consumer *streamConsumer) bool { return consumer.id == id })
+}
+
+func (c *WebsocketClient) stats(stream string, match func(*streamConsumer) bool) (StreamStats, bool) {
+	c.mu.Lock()
+	var bufs []*streamBuffer
+	for _, consumer := range c.consumers[stream] {
+		if consumer.buf != nil && match(consumer) {
+			bufs = append(bufs, consumer.buf)
+		}
+	}
+	c.mu.Unlock()
+
+	var total StreamStats
+	for _, buf := range bufs {
+		stats := buf.stats()
+		total.Queued += stats.Queued
+		total.Delivered += stats.Delivered
+		total.Dropped += stats.Dropped
+	}
+	return total, len(bufs) > 0
+}
+
+func (c *WebsocketClient) SubscribeChan(streams []string, opts ...SubscribeOption) (<-chan []byte, error) {
+	return c.SubscribeChanCtx(context.Background(), streams, opts...)
+}
+
+// SubscribeChanCtx subscribes to streams like SubscribeCtx but delivers the
+// messages on the returned channel, through a buffer of 256 messages with
+// OverflowBlock unless WithBuffer says otherwise. The channel is closed once
+// every stream is unsubscribed or the client stops.
+func (c *WebsocketClient) SubscribeChanCtx(ctx context.Context, streams []string, opts ...SubscribeOption) (<-chan []byte, error) {
+	cfg := subscribeConfig{size: defaultChanBufferSize, policy: OverflowBlock}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.size <= 0 {
+		cfg.size = defaultChanBufferSize
+	}
+
+	ch := make(chan []byte)
+	buf := newStreamBuffer(cfg, nil)
+	buf.deliver = func(data []byte) {
+		select {
+		case ch <- data:
+		case <-buf.quit:
+		}
+	}
+	buf.done = func() { close(ch) }
+	if _, err := c.subscribe(ctx, streams, nil, buf); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
@@ -0,0 +1,186 @@
+package bingxgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain returns the messages queued in b without running it.
+func drain(b *streamBuffer) []string {
+	var out []string
+	for b.stats().Queued > 0 {
+		data, _ := b.next()
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func TestStreamBufferPolicies(t *testing.T) {
+	push := func(b *streamBuffer, msgs ...string) {
+		for _, msg := range msgs {
+			stream, data, _ := strings.Cut(msg, ":")
+			b.push(stream, []byte(data))
+		}
+	}
+
+	b := newStreamBuffer(subscribeConfig{size: 2, policy: OverflowDropOldest}, nil)
+	push(b, "a:1", "a:2", "a:3")
+	assert.Equal(t, []string{"2", "3"}, drain(b))
+	assert.Equal(t, uint64(1), b.stats().Dropped)
+
+	b = newStreamBuffer(subscribeConfig{size: 2, policy: OverflowDropNewest}, nil)
+	push(b, "a:1", "a:2", "a:3")
+	assert.Equal(t, []string{"1", "2"}, drain(b))
+	assert.Equal(t, uint64(1), b.stats().Dropped)
+
+	b = newStreamBuffer(subscribeConfig{size: 2, policy: OverflowConflate}, nil)
+	push(b, "a:1", "b:1", "a:2", "a:3", "c:1")
+	// a keeps its place with its latest value, c pushes it out.
+	assert.Equal(t, []string{"1", "1"}, drain(b))
+	assert.Equal(t, uint64(3), b.stats().Dropped)
+
+	b = newStreamBuffer(subscribeConfig{size: 1, policy: OverflowBlock}, nil)
+	push(b, "a:1")
+	pushed := make(chan struct{})
+	go func() {
+		push(b, "a:2")
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push did not block on a full buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	data, _ := b.next()
+	assert.Equal(t, "1", string(data))
+	receive(t, pushed)
+	assert.Equal(t, []string{"2"}, drain(b))
+	assert.Zero(t, b.stats().Dropped)
+}
+
+func TestSubscribeBufferedHandler(t *testing.T) {
+	busy := make(chan struct{})
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		readSubscription(t, conn)
+		send := func(i int) {
+			writeGzip(conn, []byte(fmt.Sprintf(`{"dataType":"BTC-USDT@trade","data":{"p":"%d"}}`, i)))
+		}
+		send(1)
+		<-busy
+		for i := 2; i <= 5; i++ {
+			send(i)
+		}
+		conn.ReadMessage()
+	})
+	ws := NewWebsocketClient(url)
+	defer ws.Close()
+
+	var once sync.Once
+	release := make(chan struct{})
+	got := make(chan string, 5)
+	err := ws.Subscribe([]string{"BTC-USDT@trade"}, func(msg []byte) {
+		once.Do(func() { close(busy) })
+		<-release
+		got <- string(msg)
+	}, WithBuffer(1, OverflowDropNewest))
+	require.NoError(t, err)
+
+	// The handler is stuck on the first message, so of the other four one
+	// is buffered and three are dropped without stalling the reader.
+	assert.Eventually(t, func() bool {
+		stats, _ := ws.Stats("BTC-USDT@trade")
+		return stats.Dropped == 3
+	}, time.Second, 5*time.Millisecond)
+	close(release)
+	assert.Contains(t, receive(t, got), `"p":"1"`)
+	assert.Contains(t, receive(t, got), `"p":"2"`)
+
+	stats, ok := ws.Stats("BTC-USDT@trade")
+	require.True(t, ok)
+	assert.Equal(t, StreamStats{Delivered: 2, Dropped: 3}, stats)
+}
+
+func TestSubscribeChan(t *testing.T) {
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			req := readSubscription(t, conn)
+			if req == nil {
+				return
+			}
+			if req["reqType"] == "sub" {
+				writeGzip(conn, map[string]interface{}{"dataType": req["dataType"], "data": map[string]string{"p": "1"}})
+			}
+		}
+	})
+	ws := NewWebsocketClient(url)
+	defer ws.Close()
+
+	ch, err := ws.SubscribeChan([]string{"BTC-USDT@trade", "ETH-USDT@trade"})
+	require.NoError(t, err)
+	streams := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		var msg wsMessage
+		require.NoError(t, json.Unmarshal(receive(t, ch), &msg))
+		streams[msg.DataType] = true
+	}
+	assert.Len(t, streams, 2)
+
+	// The channel stays open until the last stream is unsubscribed.
+	require.NoError(t, ws.Unsubscribe([]string{"BTC-USDT@trade"}))
+	select {
+	case _, ok := <-ch:
+		require.True(t, ok, "channel closed early")
+	case <-time.After(20 * time.Millisecond):
+	}
+	require.NoError(t, ws.Unsubscribe([]string{"ETH-USDT@trade"}))
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+func TestSubscribeChanRollsBackOnRejection(t *testing.T) {
+	unsubs := make(chan string, 1)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req map[string]string
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			code := 0
+			if req["dataType"] == "ETH-USDT@nothing" {
+				code = 80015
+			}
+			writeGzip(conn, map[string]interface{}{"id": req["id"], "code": code})
+			switch {
+			case req["reqType"] == "unsub":
+				unsubs <- req["dataType"]
+			case code == 0:
+				writeGzip(conn, map[string]interface{}{"dataType": req["dataType"], "data": map[string]string{"p": "1"}})
+			}
+		}
+	})
+	ws := NewWebsocketClient(url)
+	defer ws.Close()
+
+	_, err := ws.SubscribeChan([]string{"BTC-USDT@trade", "ETH-USDT@nothing"}, WithBuffer(1, OverflowBlock))
+	assert.Equal(t, 80015, ErrorCode(err))
+	assert.Equal(t, "BTC-USDT@trade", receive(t, unsubs))
+	_, ok := ws.Stats("BTC-USDT@trade")
+	assert.False(t, ok)
+
+	// The connection still serves other requests.
+	got := make(chan []byte, 1)
+	require.NoError(t, ws.Subscribe([]string{"SOL-USDT@trade"}, func(msg []byte) { got <- msg }))
+	assert.Contains(t, string(receive(t, got)), "SOL-USDT@trade")
+}
@@ -0,0 +1,445 @@
+package bingxgo
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultMaxStreamsPerConn is the number of subscriptions BingX accepts on
+// one connection.
+const defaultMaxStreamsPerConn = 200
+
+// WebsocketPool spreads subscriptions over as many WebsocketClient
+// connections as needed to stay below BingX's limit per connection. When a
+// connection drops, the pool closes it and moves its streams to the other
+// connections, opening a new one if they are full. A move that fails to
+// connect is retried with the reconnect policy of the connections.
+type WebsocketPool struct {
+	// MaxStreamsPerConn is the number of streams subscribed on one connection
+	// before another is opened.
+	MaxStreamsPerConn int
+
+	baseURL string
+	opts    []WebsocketOption
+
+	mu      sync.Mutex
+	conns   []*poolConn
+	streams map[string]*poolStream
+	events  []func(WebsocketEvent)
+	nextID  uint64
+	closed  bool
+	done    chan struct{}
+}
+
+type poolConn struct {
+	ws      *WebsocketClient
+	streams map[string]struct{}
+}
+
+// poolStream is a stream of the pool and its consumers.
+type poolStream struct {
+	conn      *poolConn
+	consumers []*poolConsumer
+}
+
+// poolConsumer is a consumer of a stream, kept to move it elsewhere.
+type poolConsumer struct {
+	id uint64
+	// wsID is the consumer ID on the connection, zero while it is moved.
+	wsID    uint64
+	handler func([]byte)
+	opts    []SubscribeOption
+}
+
+// NewWebsocketPool creates a pool for baseURL. Its connections are created
+// with opts and opened as streams are subscribed.
+func NewWebsocketPool(baseURL string, opts ...WebsocketOption) *WebsocketPool {
+	return &WebsocketPool{
+		MaxStreamsPerConn: defaultMaxStreamsPerConn,
+		baseURL:           baseURL,
+		opts:              opts,
+		streams:           make(map[string]*poolStream),
+		done:              make(chan struct{}),
+	}
+}
+
+func (p *WebsocketPool) Subscribe(streams []string, handler func([]byte), opts ...SubscribeOption) error {
+	return p.SubscribeCtx(context.Background(), streams, handler, opts...)
+}
+
+// SubscribeCtx subscribes to streams like WebsocketClient.SubscribeCtx,
+// placing new streams on the least loaded connection with room left. A
+// stream already subscribed stays on its connection and gets handler as
+// another consumer.
+//
+// On failure the call is undone on every connection: handler leaves the
+// streams it joined and the streams new to the pool are unsubscribed.
+func (p *WebsocketPool) SubscribeCtx(ctx context.Context, streams []string, handler func([]byte), opts ...SubscribeOption) error {
+	_, err := p.subscribeConsumer(ctx, streams, handler, opts)
+	return err
+}
+
+// subscribeConsumer subscribes like SubscribeCtx and returns the consumer
+// ID for unsubscribeConsumer.
+func (p *WebsocketPool) subscribeConsumer(ctx context.Context, streams []string, handler func([]byte), opts []SubscribeOption) (uint64, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return 0, ErrWebsocketClosed
+	}
+	p.nextID++
+	id := p.nextID
+	groups := make(map[*poolConn][]string)
+	var order []*poolConn
+	consumers := make(map[string]*poolConsumer, len(streams))
+	for _, stream := range streams {
+		s, ok := p.streams[stream]
+		if !ok {
+			s = &poolStream{conn: p.pick()}
+			s.conn.streams[stream] = struct{}{}
+			p.streams[stream] = s
+		}
+		// Recorded before subscribing, so watch moves it with the stream.
+		consumer := &poolConsumer{id: id, handler: handler, opts: opts}
+		s.consumers = append(slices.Clone(s.consumers), consumer)
+		consumers[stream] = consumer
+		if _, ok := groups[s.conn]; !ok {
+			order = append(order, s.conn)
+		}
+		groups[s.conn] = append(groups[s.conn], stream)
+	}
+	p.mu.Unlock()
+
+	for _, conn := range order {
+		wsID, err := conn.ws.subscribeConsumer(ctx, groups[conn], handler, opts)
+		if err != nil {
+			// Streams left without consumers are dropped with it.
+			_ = p.unsubscribeConsumer(context.WithoutCancel(ctx), streams, id)
+			return 0, err
+		}
+		p.mu.Lock()
+		for _, stream := range groups[conn] {
+			// A stream moved meanwhile was subscribed again by watch.
+			if s, ok := p.streams[stream]; ok && s.conn == conn {
+				consumers[stream].wsID = wsID
+			}
+		}
+		p.mu.Unlock()
+	}
+	return id, nil
+}
+
+// pick returns the least loaded connection with room for another stream,
+// opening one if all are full. The caller holds p.mu.
+func (p *WebsocketPool) pick() *poolConn {
+	limit := p.MaxStreamsPerConn
+	if limit <= 0 {
+		limit = defaultMaxStreamsPerConn
+	}
+	var best *poolConn
+	for _, conn := range p.conns {
+		if len(conn.streams) >= limit {
+			continue
+		}
+		if best == nil || len(conn.streams) < len(best.streams) {
+			best = conn
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	ws := NewWebsocketClient(p.baseURL, p.opts...)
+	ws.OnEvent(func(e WebsocketEvent) {
+		if e.Kind == WebsocketDisconnected {
+			// Stopping it hands its streams to watch, which moves them
+			// instead of waiting for this connection to come back.
+			ws.stop(e.Err)
+		}
+	})
+	conn := &poolConn{ws: ws, streams: make(map[string]struct{})}
+	for _, fn := range p.events {
+		conn.ws.OnEvent(fn)
+	}
+	p.conns = append(p.conns, conn)
+	go p.watch(conn)
+	return conn
+}
+
+// dropStream removes stream from the pool and returns its connection when
+// that is left without streams and taken out of the pool too. The caller
+// holds p.mu and closes the returned connection.
+func (p *WebsocketPool) dropStream(stream string) *poolConn {
+	s, ok := p.streams[stream]
+	if !ok {
+		return nil
+	}
+	delete(p.streams, stream)
+	delete(s.conn.streams, stream)
+	if len(s.conn.streams) == 0 && p.removeConn(s.conn) {
+		return s.conn
+	}
+	return nil
+}
+
+func (p *WebsocketPool) Unsubscribe(streams []string) error {
+	return p.UnsubscribeCtx(context.Background(), streams)
+}
+
+// UnsubscribeCtx removes every consumer of streams, unsubscribes from them
+// and closes connections left without streams.
+func (p *WebsocketPool) UnsubscribeCtx(ctx context.Context, streams []string) error {
+	p.mu.Lock()
+	groups := make(map[*poolConn][]string)
+	var order, idle []*poolConn
+	for _, stream := range streams {
+		s, ok := p.streams[stream]
+		if !ok {
+			continue
+		}
+		if conn := p.dropStream(stream); conn != nil {
+			idle = append(idle, conn)
+		}
+		if _, ok := groups[s.conn]; !ok {
+			order = append(order, s.conn)
+		}
+		groups[s.conn] = append(groups[s.conn], stream)
+	}
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, conn := range order {
+		if slices.Contains(idle, conn) {
+			// Closing drops the subscriptions.
+			conn.ws.Close()
+			continue
+		}
+		if err := conn.ws.UnsubscribeCtx(ctx, groups[conn]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// unsubscribeConsumer removes consumer id from streams. Streams left without
+// consumers are unsubscribed and connections left without streams closed.
+func (p *WebsocketPool) unsubscribeConsumer(ctx context.Context, streams []string, id uint64) error {
+	type target struct {
+		conn *poolConn
+		wsID uint64
+	}
+	p.mu.Lock()
+	groups := make(map[target][]string)
+	var order []target
+	var idle []*poolConn
+	for _, stream := range streams {
+		s, ok := p.streams[stream]
+		if !ok {
+			continue
+		}
+		i := slices.IndexFunc(s.consumers, func(c *poolConsumer) bool { return c.id == id })
+		if i < 0 {
+			continue
+		}
+		t := target{conn: s.conn, wsID: s.consumers[i].wsID}
+		s.consumers = slices.Delete(slices.Clone(s.consumers), i, i+1)
+		if len(s.consumers) == 0 {
+			if conn := p.dropStream(stream); conn != nil {
+				idle = append(idle, conn)
+			}
+		}
+		if _, ok := groups[t]; !ok {
+			order = append(order, t)
+		}
+		groups[t] = append(groups[t], stream)
+	}
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, t := range order {
+		if slices.Contains(idle, t.conn) {
+			continue
+		}
+		if err := t.conn.ws.unsubscribeConsumer(ctx, groups[t], t.wsID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, conn := range idle {
+		// Closing drops the subscriptions.
+		conn.ws.Close()
+	}
+	return firstErr
+}
+
+// removeConn takes conn out of the pool. The caller holds p.mu.
+func (p *WebsocketPool) removeConn(conn *poolConn) bool {
+	i := slices.Index(p.conns, conn)
+	if i < 0 {
+		return false
+	}
+	p.conns = slices.Delete(p.conns, i, i+1)
+	return true
+}
+
+// watch moves the streams of conn and their consumers once it stops.
+// Consumers that cannot be moved are dropped, the event reports the first
+// failure.
+func (p *WebsocketPool) watch(conn *poolConn) {
+	<-conn.ws.Done()
+
+	p.mu.Lock()
+	if p.closed || !p.removeConn(conn) {
+		p.mu.Unlock()
+		return
+	}
+	streams := make([]string, 0, len(conn.streams))
+	for stream := range conn.streams {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	moved := make([]*poolStream, len(streams))
+	consumers := make([][]*poolConsumer, len(streams))
+	for i, stream := range streams {
+		s := p.streams[stream]
+		s.conn = p.pick()
+		s.conn.streams[stream] = struct{}{}
+		for _, consumer := range s.consumers {
+			consumer.wsID = 0
+		}
+		moved[i], consumers[i] = s, slices.Clone(s.consumers)
+	}
+	p.mu.Unlock()
+
+	event := WebsocketEvent{Kind: WebsocketRebalanced, Streams: streams}
+	for i, stream := range streams {
+		for _, consumer := range consumers[i] {
+			if err := p.move(moved[i], stream, consumer); err != nil {
+				p.unsubscribeConsumer(context.Background(), []string{stream}, consumer.id)
+				if event.Err == nil {
+					event.Err = err
+				}
+			}
+		}
+	}
+
+	p.mu.Lock()
+	closed, events := p.closed, p.events
+	p.mu.Unlock()
+	if closed {
+		return
+	}
+	for _, fn := range events {
+		fn(event)
+	}
+}
+
+// move subscribes consumer to stream on the connection s was moved to.
+// Failing to connect is retried with backoff, as the new connection may be
+// cut off like the old one, until the reconnect policy gives up.
+func (p *WebsocketPool) move(s *poolStream, stream string, consumer *poolConsumer) error {
+	p.mu.Lock()
+	conn := s.conn
+	p.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		wsID, err := conn.ws.subscribeConsumer(context.Background(), []string{stream}, consumer.handler, consumer.opts)
+
+		p.mu.Lock()
+		// The stream may have moved on again, or the consumer left.
+		stale := s.conn != conn || p.streams[stream] != s || !slices.Contains(s.consumers, consumer)
+		if err == nil && !stale {
+			consumer.wsID = wsID
+		}
+		p.mu.Unlock()
+		if stale {
+			if err == nil {
+				_ = conn.ws.unsubscribeConsumer(context.Background(), []string{stream}, wsID)
+			}
+			return nil
+		}
+
+		policy := conn.ws.reconnect
+		if err == nil || ErrorCode(err) != 0 || policy == nil || (policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts) {
+			return err
+		}
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-p.done:
+			timer.Stop()
+			return ErrWebsocketClosed
+		}
+	}
+}
+
+// OnEvent registers fn to be called on the events of every connection of
+// the pool, and on WebsocketRebalanced.
+func (p *WebsocketPool) OnEvent(fn func(WebsocketEvent)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, fn)
+	for _, conn := range p.conns {
+		conn.ws.OnEvent(fn)
+	}
+}
+
+// Stats returns the counters of a stream subscribed with WithBuffer.
+func (p *WebsocketPool) Stats(stream string) (StreamStats, bool) {
+	p.mu.Lock()
+	s, ok := p.streams[stream]
+	p.mu.Unlock()
+	if !ok {
+		return StreamStats{}, false
+	}
+	return s.conn.ws.Stats(stream)
+}
+
+// consumerStats returns the counters of consumer id of stream.
+func (p *WebsocketPool) consumerStats(stream string, id uint64) (StreamStats, bool) {
+	p.mu.Lock()
+	var conn *poolConn
+	var wsID uint64
+	if s, ok := p.streams[stream]; ok {
+		for _, consumer := range s.consumers {
+			if consumer.id == id {
+				conn, wsID = s.conn, consumer.wsID
+			}
+		}
+	}
+	p.mu.Unlock()
+	if conn == nil {
+		return StreamStats{}, false
+	}
+	return conn.ws.consumerStats(stream, wsID)
+}
+
+// Connections returns the number of connections in use.
+func (p *WebsocketPool) Connections() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.conns)
+}
+
+// Close closes every connection of the pool.
+func (p *WebsocketPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	conns := p.conns
+	p.conns = nil
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, conn := range conns {
+		if err := conn.ws.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
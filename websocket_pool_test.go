@@ -0,0 +1,180 @@
+package bingxgo
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebsocketPoolShardsStreams(t *testing.T) {
+	var conns, subs atomic.Int32
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		conns.Add(1)
+		for {
+			req := readSubscription(t, conn)
+			if req == nil {
+				return
+			}
+			if req["reqType"] == "sub" {
+				subs.Add(1)
+			}
+		}
+	})
+	pool := NewWebsocketPool(url)
+	pool.MaxStreamsPerConn = 2
+	defer pool.Close()
+
+	handler := func([]byte) {}
+	require.NoError(t, pool.Subscribe([]string{"A@trade", "B@trade", "C@trade"}, handler))
+	require.NoError(t, pool.Subscribe([]string{"D@trade", "E@trade"}, handler))
+	assert.Equal(t, 3, pool.Connections())
+	assert.Equal(t, int32(3), conns.Load())
+	assert.Equal(t, int32(5), subs.Load())
+
+	// Subscribing again keeps the stream where it is.
+	require.NoError(t, pool.Subscribe([]string{"A@trade"}, handler))
+	assert.Equal(t, 3, pool.Connections())
+
+	// E has the third connection to itself, which closes without it.
+	require.NoError(t, pool.Unsubscribe([]string{"E@trade"}))
+	assert.Equal(t, 2, pool.Connections())
+	require.NoError(t, pool.Subscribe([]string{"F@trade"}, handler))
+	assert.Equal(t, 3, pool.Connections())
+}
+
+func TestWebsocketPoolRebalances(t *testing.T) {
+	var conns atomic.Int32
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		first := conns.Add(1) == 1
+		for i := 0; ; i++ {
+			req := readSubscription(t, conn)
+			if req == nil {
+				return
+			}
+			if first {
+				if i == 1 {
+					// The first connection fails after its two subscriptions.
+					return
+				}
+				continue
+			}
+			writeGzip(conn, map[string]interface{}{"dataType": req["dataType"], "data": map[string]string{"p": "1"}})
+		}
+	})
+	pool := NewWebsocketPool(url, WithReconnectPolicy(nil))
+	pool.MaxStreamsPerConn = 2
+	defer pool.Close()
+	rebalanced := make(chan WebsocketEvent, 1)
+	pool.OnEvent(func(e WebsocketEvent) {
+		if e.Kind == WebsocketRebalanced {
+			rebalanced <- e
+		}
+	})
+
+	got := make(chan string, 10)
+	require.NoError(t, pool.Subscribe([]string{"A@trade", "B@trade"}, func(msg []byte) {
+		var m wsMessage
+		json.Unmarshal(msg, &m)
+		got <- m.DataType
+	}))
+	require.NoError(t, pool.Subscribe([]string{"C@trade"}, func([]byte) {}))
+
+	event := receive(t, rebalanced)
+	assert.NoError(t, event.Err)
+	assert.Equal(t, []string{"A@trade", "B@trade"}, event.Streams)
+	streams := map[string]bool{receive(t, got): true, receive(t, got): true}
+	assert.Equal(t, map[string]bool{"A@trade": true, "B@trade": true}, streams)
+	// The failed connection is gone, its streams took a third one.
+	assert.Equal(t, 2, pool.Connections())
+	assert.Equal(t, int32(3), conns.Load())
+}
+
+func TestWebsocketPoolRollsBackFailedSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req map[string]string
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			mu.Lock()
+			requests = append(requests, req["reqType"]+" "+req["dataType"])
+			mu.Unlock()
+			code := 0
+			if req["dataType"] == "D@trade" {
+				code = 80015
+			}
+			writeGzip(conn, map[string]interface{}{"id": req["id"], "code": code})
+		}
+	})
+	pool := NewWebsocketPool(url)
+	pool.MaxStreamsPerConn = 3
+	defer pool.Close()
+
+	handler := func([]byte) {}
+	require.NoError(t, pool.Subscribe([]string{"A@trade", "B@trade"}, handler))
+	// A stays and C joins it on the first connection, D to F take a second.
+	err := pool.Subscribe([]string{"A@trade", "C@trade", "D@trade", "E@trade", "F@trade"}, handler)
+	assert.Equal(t, 80015, ErrorCode(err))
+
+	// C is unsubscribed again, the emptied second connection closes.
+	assert.Equal(t, 1, pool.Connections())
+	mu.Lock()
+	assert.Contains(t, requests, "unsub C@trade")
+	mu.Unlock()
+
+	// A and B are still counted on the first connection, which has room for
+	// one more stream.
+	require.NoError(t, pool.Subscribe([]string{"G@trade"}, handler))
+	assert.Equal(t, 1, pool.Connections())
+	require.NoError(t, pool.Subscribe([]string{"H@trade"}, handler))
+	assert.Equal(t, 2, pool.Connections())
+}
+
+func TestWebsocketPoolRebalancesOnDisconnect(t *testing.T) {
+	var conns atomic.Int32
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		first := conns.Add(1) == 1
+		for {
+			req := readSubscription(t, conn)
+			if req == nil {
+				return
+			}
+			if first {
+				// The first connection drops after its subscription.
+				return
+			}
+			writeGzip(conn, map[string]interface{}{"dataType": req["dataType"], "data": map[string]string{"p": "1"}})
+		}
+	})
+	// The default reconnect policy never gives up, the pool moves the
+	// streams without waiting for it.
+	pool := NewWebsocketPool(url)
+	defer pool.Close()
+	rebalanced := make(chan WebsocketEvent, 1)
+	pool.OnEvent(func(e WebsocketEvent) {
+		if e.Kind == WebsocketRebalanced {
+			rebalanced <- e
+		}
+	})
+
+	got := make(chan string, 1)
+	require.NoError(t, pool.Subscribe([]string{"A@trade"}, func(msg []byte) {
+		var m wsMessage
+		json.Unmarshal(msg, &m)
+		got <- m.DataType
+	}))
+
+	event := receive(t, rebalanced)
+	assert.NoError(t, event.Err)
+	assert.Equal(t, []string{"A@trade"}, event.Streams)
+	assert.Equal(t, "A@trade", receive(t, got))
+	assert.Equal(t, 1, pool.Connections())
+	assert.Equal(t, int32(2), conns.Load())
+}
@@ -0,0 +1,172 @@
+package bingxgo
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ReconnectPolicy controls how a WebsocketClient reconnects after its
+// connection drops.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive failed dials after which the
+	// client stops, unlimited when zero.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64 // fraction of the backoff randomised, 0..1
+}
+
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	return exponentialBackoff(p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter, attempt)
+}
+
+// WithReconnectPolicy replaces DefaultReconnectPolicy. A nil policy disables
+// reconnection, so the client stops on the first connection failure.
+func WithReconnectPolicy(policy *ReconnectPolicy) WebsocketOption {
+	return func(c *WebsocketClient) {
+		c.reconnect = policy
+	}
+}
+
+// WithHeartbeatTimeout sets how long a connection may receive nothing,
+// heartbeats included, before it is dropped as stale. Zero disables the check.
+func WithHeartbeatTimeout(d time.Duration) WebsocketOption {
+	return func(c *WebsocketClient) {
+		c.heartbeatTimeout = d
+	}
+}
+
+type WebsocketEventKind int
+
+const (
+	// WebsocketConnected is emitted after every successful dial.
+	WebsocketConnected WebsocketEventKind = iota
+	// WebsocketDisconnected is emitted when a connection drops, with the cause.
+	WebsocketDisconnected
+	// WebsocketResubscribed is emitted after subscriptions were replayed on a
+	// new connection.
+	WebsocketResubscribed
+	// WebsocketRebalanced is emitted by a WebsocketPool after it moved the
+	// streams of a failed connection to other connections.
+	WebsocketRebalanced
+)
+
+func (k WebsocketEventKind) String() string {
+	switch k {
+	case WebsocketConnected:
+		return "connected"
+	case WebsocketDisconnected:
+		return "disconnected"
+	case WebsocketResubscribed:
+		return "resubscribed"
+	case WebsocketRebalanced:
+		return "rebalanced"
+	default:
+		return "unknown"
+	}
+}
+
+// WebsocketEvent reports a change of the connection. Data received before a
+// disconnect may be stale after it, so consumers holding state derived from
+// a stream should rebuild it on WebsocketResubscribed.
+type WebsocketEvent struct {
+	Kind WebsocketEventKind
+	// Err is the cause of a disconnect, or the first failed resubscription.
+	Err error
+	// Attempt is the reconnection attempt that connected, zero for the
+	// initial connection.
+	Attempt int
+	// Streams lists the streams replayed on WebsocketResubscribed or moved on
+	// WebsocketRebalanced.
+	Streams []string
+}
+
+// OnEvent registers fn to be called on every connection event. Handlers are
+// called from the client's goroutines and must not block.
+func (c *WebsocketClient) OnEvent(fn func(WebsocketEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, fn)
+}
+
+func (c *WebsocketClient) emit(event WebsocketEvent) {
+	c.mu.Lock()
+	handlers := c.events
+	c.mu.Unlock()
+	for _, fn := range handlers {
+		fn(event)
+	}
+}
+
+// redial reconnects with backoff until it succeeds, the policy gives up or
+// the client is closed.
+func (c *WebsocketClient) redial(cause error) {
+	for attempt := 1; ; attempt++ {
+		if c.reconnect.MaxAttempts > 0 && attempt > c.reconnect.MaxAttempts {
+			c.stop(cause)
+			return
+		}
+
+		timer := time.NewTimer(c.reconnect.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-c.done:
+			timer.Stop()
+			return
+		}
+
+		sess, err := c.dial(context.Background())
+		if err != nil {
+			cause = err
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			sess.conn.Close()
+			return
+		}
+		c.sess = sess
+		c.mu.Unlock()
+
+		go c.serve(sess)
+		c.emit(WebsocketEvent{Kind: WebsocketConnected, Attempt: attempt})
+		c.resubscribe(sess)
+		return
+	}
+}
+
+// resubscribe replays every active subscription on sess.
+func (c *WebsocketClient) resubscribe(sess *wsSession) {
+	c.mu.Lock()
+	streams := make([]string, 0, len(c.consumers))
+	for stream := range c.consumers {
+		streams = append(streams, stream)
+	}
+	c.mu.Unlock()
+	sort.Strings(streams)
+
+	event := WebsocketEvent{Kind: WebsocketResubscribed, Streams: make([]string, 0, len(streams))}
+	for _, stream := range streams {
+		if err := c.request(context.Background(), sess, "sub", stream); err != nil {
+			if event.Err == nil {
+				event.Err = err
+			}
+			continue
+		}
+		event.Streams = append(event.Streams, stream)
+	}
+	c.emit(event)
+}
@@ -0,0 +1,350 @@
+package bingxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrStreamUnsupported is returned when a market has no such stream.
+var ErrStreamUnsupported = errors.New("bingx: stream not supported by this market")
+
+// StreamSubscriber subscribes to BingX streams. It is implemented by
+// WebsocketClient and WebsocketPool.
+type StreamSubscriber interface {
+	SubscribeCtx(ctx context.Context, streams []string, handler func([]byte), opts ...SubscribeOption) error
+	UnsubscribeCtx(ctx context.Context, streams []string) error
+	Stats(stream string) (StreamStats, bool)
+
+	subscribeConsumer(ctx context.Context, streams []string, handler func([]byte), opts []SubscribeOption) (uint64, error)
+	unsubscribeConsumer(ctx context.Context, streams []string, id uint64) error
+	consumerStats(stream string, id uint64) (StreamStats, bool)
+}
+
+// Subscription is an active typed stream subscription. Subscriptions to the
+// same stream share it, the stream is unsubscribed when the last one is.
+type Subscription struct {
+	ws     StreamSubscriber
+	stream string
+	id     uint64
+}
+
+// Stream returns the BingX dataType of the subscription, e.g. "BTC-USDT@trade".
+func (s *Subscription) Stream() string {
+	return s.stream
+}
+
+// Stats returns the counters of a subscription made with WithBuffer.
+func (s *Subscription) Stats() (StreamStats, bool) {
+	return s.ws.consumerStats(s.stream, s.id)
+}
+
+func (s *Subscription) Unsubscribe() error {
+	return s.UnsubscribeCtx(context.Background())
+}
+
+func (s *Subscription) UnsubscribeCtx(ctx context.Context) error {
+	return s.ws.unsubscribeConsumer(ctx, []string{s.stream}, s.id)
+}
+
+// MarketStream decodes the market data streams of a WebsocketClient or
+// WebsocketPool. Spot and swap streams differ in naming and payloads, so the
+// stream must match the client's endpoint.
+type MarketStream struct {
+	ws   StreamSubscriber
+	swap bool
+}
+
+// NewSpotMarketStream wraps a client or pool connected to SpotMarketWSURL.
+func NewSpotMarketStream(ws StreamSubscriber) MarketStream {
+	return MarketStream{ws: ws}
+}
+
+// NewSwapMarketStream wraps a client or pool connected to SwapMarketWSURL.
+func NewSwapMarketStream(ws StreamSubscriber) MarketStream {
+	return MarketStream{ws: ws, swap: true}
+}
+
+// subscribeStream subscribes to stream and passes every event decoded from
+// its data to handler. Messages that fail to decode are dropped.
+func subscribeStream[T any](ctx context.Context, ws StreamSubscriber, stream string, decode func(json.RawMessage) ([]T, error), handler func(T), opts []SubscribeOption) (*Subscription, error) {
+	raw := func(msg []byte) {
+		var m wsMessage
+		if err := json.Unmarshal(msg, &m); err != nil {
+			return
+		}
+		events, err := decode(m.Data)
+		if err != nil {
+			return
+		}
+		for _, event := range events {
+			handler(event)
+		}
+	}
+	id, err := ws.subscribeConsumer(ctx, []string{stream}, raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Subscription{ws: ws, stream: stream, id: id}, nil
+}
+
+// decodeList decodes data holding either one object or a list of them.
+func decodeList[T any](data json.RawMessage) ([]T, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var items []T
+		err := json.Unmarshal(data, &items)
+		return items, err
+	}
+	var item T
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return []T{item}, nil
+}
+
+// wsHeader holds the event type and time sent with most stream payloads.
+// Both are declared so neither is matched case-insensitively to the other.
+type wsHeader struct {
+	Type string `json:"e"`
+	Time int64  `json:"E"`
+}
+
+// wsID accepts IDs sent as JSON strings or numbers.
+type wsID string
+
+func (id *wsID) UnmarshalJSON(data []byte) error {
+	*id = wsID(strings.Trim(string(data), `"`))
+	return nil
+}
+
+func (m MarketStream) SubscribeDepth(symbol string, levels int, handler func(DepthEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeDepthCtx(context.Background(), symbol, levels, handler, opts...)
+}
+
+// SubscribeDepthCtx streams the top levels of the order book, with levels
+// one of 5, 10, 20, 50 or 100.
+func (m MarketStream) SubscribeDepthCtx(ctx context.Context, symbol string, levels int, handler func(DepthEvent), opts ...SubscribeOption) (*Subscription, error) {
+	stream := fmt.Sprintf("%s@depth%d", symbol, levels)
+	if m.swap {
+		stream += "@500ms"
+	}
+	return subscribeStream(ctx, m.ws, stream, func(data json.RawMessage) ([]DepthEvent, error) {
+		event := DepthEvent{Symbol: symbol}
+		err := json.Unmarshal(data, &event.OrderBook)
+		return []DepthEvent{event}, err
+	}, handler, opts)
+}
+
+func (m MarketStream) SubscribeIncrDepth(symbol string, handler func(DepthUpdateEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeIncrDepthCtx(context.Background(), symbol, handler, opts...)
+}
+
+// SubscribeIncrDepthCtx streams order book changes, starting with a full
+// snapshot.
+func (m MarketStream) SubscribeIncrDepthCtx(ctx context.Context, symbol string, handler func(DepthUpdateEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return subscribeStream(ctx, m.ws, incrDepthStream(symbol), func(data json.RawMessage) ([]DepthUpdateEvent, error) {
+		var raw struct {
+			Action       string `json:"action"`
+			LastUpdateID int64  `json:"lastUpdateId"`
+			OrderBook
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return []DepthUpdateEvent{{
+			Symbol:       symbol,
+			Action:       raw.Action,
+			LastUpdateID: raw.LastUpdateID,
+			OrderBook:    raw.OrderBook,
+		}}, nil
+	}, handler, opts)
+}
+
+func incrDepthStream(symbol string) string {
+	return symbol + "@incrDepth"
+}
+
+type wsTrade struct {
+	wsHeader
+	ID         wsID    `json:"t"`
+	Price      Decimal `json:"p"`
+	Qty        Decimal `json:"q"`
+	Time       int64   `json:"T"`
+	BuyerMaker bool    `json:"m"`
+}
+
+func (m MarketStream) SubscribeTrades(symbol string, handler func(TradeEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeTradesCtx(context.Background(), symbol, handler, opts...)
+}
+
+func (m MarketStream) SubscribeTradesCtx(ctx context.Context, symbol string, handler func(TradeEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return subscribeStream(ctx, m.ws, symbol+"@trade", func(data json.RawMessage) ([]TradeEvent, error) {
+		trades, err := decodeList[wsTrade](data)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]TradeEvent, 0, len(trades))
+		for _, t := range trades {
+			events = append(events, TradeEvent{
+				Symbol:     symbol,
+				BuyerMaker: t.BuyerMaker,
+				Trade: Trade{
+					Timestamp: t.Time,
+					TradeId:   string(t.ID),
+					Price:     t.Price,
+					Volume:    t.Qty,
+					Amount:    t.Price.Mul(t.Qty),
+				},
+			})
+		}
+		return events, nil
+	}, handler, opts)
+}
+
+type wsKline struct {
+	OpenTime  int64   `json:"t"`
+	CloseTime int64   `json:"T"`
+	Open      Decimal `json:"o"`
+	High      Decimal `json:"h"`
+	Low       Decimal `json:"l"`
+	Close     Decimal `json:"c"`
+	Volume    Decimal `json:"v"`
+}
+
+func (k wsKline) kline(swap bool) Kline {
+	kline := Kline{Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume, Time: k.OpenTime}
+	if swap {
+		// Swap klines carry only their start time, as "T".
+		kline.Time = k.CloseTime
+	}
+	return kline
+}
+
+func (m MarketStream) SubscribeKlines(symbol, interval string, handler func(KlineEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeKlinesCtx(context.Background(), symbol, interval, handler, opts...)
+}
+
+// SubscribeKlinesCtx streams the current candle of interval, e.g. "1min" or
+// "1hour" on spot and "1m" or "1h" on swap.
+func (m MarketStream) SubscribeKlinesCtx(ctx context.Context, symbol, interval string, handler func(KlineEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return subscribeStream(ctx, m.ws, symbol+"@kline_"+interval, func(data json.RawMessage) ([]KlineEvent, error) {
+		var klines []wsKline
+		if m.swap {
+			var err error
+			if klines, err = decodeList[wsKline](data); err != nil {
+				return nil, err
+			}
+		} else {
+			var raw struct {
+				Kline wsKline `json:"K"`
+			}
+			if err := json.Unmarshal(data, &raw); err != nil {
+				return nil, err
+			}
+			klines = []wsKline{raw.Kline}
+		}
+
+		events := make([]KlineEvent, 0, len(klines))
+		for _, k := range klines {
+			events = append(events, KlineEvent{Symbol: symbol, Interval: interval, Kline: k.kline(m.swap)})
+		}
+		return events, nil
+	}, handler, opts)
+}
+
+func (m MarketStream) SubscribeTicker(symbol string, handler func(TickerEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeTickerCtx(context.Background(), symbol, handler, opts...)
+}
+
+func (m MarketStream) SubscribeTickerCtx(ctx context.Context, symbol string, handler func(TickerEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return subscribeStream(ctx, m.ws, symbol+"@ticker", func(data json.RawMessage) ([]TickerEvent, error) {
+		var raw struct {
+			wsHeader
+			PriceChange        Decimal `json:"p"`
+			PriceChangePercent string  `json:"P"`
+			Open               Decimal `json:"o"`
+			OpenTime           int64   `json:"O"`
+			High               Decimal `json:"h"`
+			Low                Decimal `json:"l"`
+			LastQty            Decimal `json:"L"`
+			Last               Decimal `json:"c"`
+			CloseTime          int64   `json:"C"`
+			Volume             Decimal `json:"v"`
+			QuoteVolume        Decimal `json:"q"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return []TickerEvent{{
+			Symbol:             symbol,
+			EventTime:          raw.Time,
+			PriceChange:        raw.PriceChange,
+			PriceChangePercent: raw.PriceChangePercent,
+			Open:               raw.Open,
+			High:               raw.High,
+			Low:                raw.Low,
+			Last:               raw.Last,
+			Volume:             raw.Volume,
+			QuoteVolume:        raw.QuoteVolume,
+			OpenTime:           raw.OpenTime,
+			CloseTime:          raw.CloseTime,
+		}}, nil
+	}, handler, opts)
+}
+
+func (m MarketStream) SubscribeBookTicker(symbol string, handler func(BookTickerEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeBookTickerCtx(context.Background(), symbol, handler, opts...)
+}
+
+// SubscribeBookTickerCtx streams the best bid and ask.
+func (m MarketStream) SubscribeBookTickerCtx(ctx context.Context, symbol string, handler func(BookTickerEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return subscribeStream(ctx, m.ws, symbol+"@bookTicker", func(data json.RawMessage) ([]BookTickerEvent, error) {
+		var raw struct {
+			wsHeader
+			UpdateID  int64   `json:"u"`
+			TradeTime int64   `json:"T"`
+			BidPrice  Decimal `json:"b"`
+			BidQty    Decimal `json:"B"`
+			AskPrice  Decimal `json:"a"`
+			AskQty    Decimal `json:"A"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return []BookTickerEvent{{
+			Symbol:    symbol,
+			UpdateID:  raw.UpdateID,
+			EventTime: raw.Time,
+			BidPrice:  raw.BidPrice,
+			BidQty:    raw.BidQty,
+			AskPrice:  raw.AskPrice,
+			AskQty:    raw.AskQty,
+		}}, nil
+	}, handler, opts)
+}
+
+func (m MarketStream) SubscribeMarkPrice(symbol string, handler func(MarkPriceEvent), opts ...SubscribeOption) (*Subscription, error) {
+	return m.SubscribeMarkPriceCtx(context.Background(), symbol, handler, opts...)
+}
+
+// SubscribeMarkPriceCtx streams the mark price of a perpetual contract. Spot
+// markets have no mark price and return ErrStreamUnsupported.
+func (m MarketStream) SubscribeMarkPriceCtx(ctx context.Context, symbol string, handler func(MarkPriceEvent), opts ...SubscribeOption) (*Subscription, error) {
+	if !m.swap {
+		return nil, ErrStreamUnsupported
+	}
+	return subscribeStream(ctx, m.ws, symbol+"@markPrice", func(data json.RawMessage) ([]MarkPriceEvent, error) {
+		var raw struct {
+			wsHeader
+			Price Decimal `json:"p"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return []MarkPriceEvent{{Symbol: symbol, EventTime: raw.Time, MarkPrice: raw.Price}}, nil
+	}, handler, opts)
+}
@@ -0,0 +1,227 @@
+package bingxgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer accepts every request and answers each subscription with
+// the payload registered for its dataType. Unsubscribed streams are sent to
+// unsubs.
+func newStreamServer(t *testing.T, payloads map[string]string, unsubs chan<- string) *WebsocketClient {
+	t.Helper()
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req map[string]string
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 0})
+			switch req["reqType"] {
+			case "sub":
+				data := payloads[req["dataType"]]
+				writeGzip(conn, []byte(`{"code":0,"dataType":"`+req["dataType"]+`","data":`+data+`}`))
+			case "unsub":
+				unsubs <- req["dataType"]
+			}
+		}
+	})
+	ws := NewWebsocketClient(url)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		var zero T
+		return zero
+	}
+}
+
+func TestSpotMarketStreams(t *testing.T) {
+	unsubs := make(chan string, 1)
+	ws := newStreamServer(t, map[string]string{
+		"BTC-USDT@trade":      `{"e":"trade","E":1700000000001,"s":"BTC-USDT","t":"42","p":"37000.5","q":"0.2","T":1700000000000,"m":true}`,
+		"BTC-USDT@kline_1min": `{"e":"kline","E":1,"s":"BTC-USDT","K":{"t":1700000000000,"T":1700000059999,"s":"BTC-USDT","i":"1min","o":"1","c":"2","h":"3","l":"0.5","v":"10","n":5,"q":"20"}}`,
+		"BTC-USDT@depth5":     `{"bids":[["36999","1.5"]],"asks":[["37001","0.25"]]}`,
+		"BTC-USDT@ticker":     `{"e":"24hTicker","E":7,"s":"BTC-USDT","p":"100","P":"0.27%","o":"36900","h":"37100","l":"36800","c":"37000","v":"12","q":"444000","O":1,"C":2,"B":"36999","b":"1","A":"37001","a":"2"}`,
+		"BTC-USDT@bookTicker": `{"e":"bookTicker","u":9,"E":8,"s":"BTC-USDT","b":"36999","B":"1.5","a":"37001","A":"0.25"}`,
+		"BTC-USDT@incrDepth":  `{"action":"update","lastUpdateId":12,"bids":[["36999","0"]],"asks":[]}`,
+	}, unsubs)
+	spot := NewSpotMarketStream(ws)
+
+	trades := make(chan TradeEvent, 1)
+	sub, err := spot.SubscribeTrades("BTC-USDT", func(e TradeEvent) { trades <- e })
+	require.NoError(t, err)
+	trade := receive(t, trades)
+	assert.Equal(t, "BTC-USDT", trade.Symbol)
+	assert.Equal(t, "42", trade.TradeId)
+	assert.Equal(t, int64(1700000000000), trade.Timestamp)
+	assert.True(t, trade.BuyerMaker)
+	assert.Equal(t, "7400.1", trade.Amount.String())
+
+	klines := make(chan KlineEvent, 1)
+	_, err = spot.SubscribeKlines("BTC-USDT", "1min", func(e KlineEvent) { klines <- e })
+	require.NoError(t, err)
+	kline := receive(t, klines)
+	assert.Equal(t, "1min", kline.Interval)
+	assert.Equal(t, int64(1700000000000), kline.Time)
+	assert.Equal(t, "3", kline.High.String())
+
+	depths := make(chan DepthEvent, 1)
+	_, err = spot.SubscribeDepth("BTC-USDT", 5, func(e DepthEvent) { depths <- e })
+	require.NoError(t, err)
+	depth := receive(t, depths)
+	assert.Equal(t, "36999", depth.Bids[0][0].String())
+	assert.Equal(t, "0.25", depth.Asks[0][1].String())
+
+	tickers := make(chan TickerEvent, 1)
+	_, err = spot.SubscribeTicker("BTC-USDT", func(e TickerEvent) { tickers <- e })
+	require.NoError(t, err)
+	ticker := receive(t, tickers)
+	assert.Equal(t, "0.27%", ticker.PriceChangePercent)
+	assert.Equal(t, "37000", ticker.Last.String())
+	assert.Equal(t, "36800", ticker.Low.String())
+
+	books := make(chan BookTickerEvent, 1)
+	_, err = spot.SubscribeBookTicker("BTC-USDT", func(e BookTickerEvent) { books <- e })
+	require.NoError(t, err)
+	book := receive(t, books)
+	assert.Equal(t, "36999", book.BidPrice.String())
+	assert.Equal(t, "0.25", book.AskQty.String())
+
+	updates := make(chan DepthUpdateEvent, 1)
+	_, err = spot.SubscribeIncrDepth("BTC-USDT", func(e DepthUpdateEvent) { updates <- e })
+	require.NoError(t, err)
+	update := receive(t, updates)
+	assert.Equal(t, "update", update.Action)
+	assert.Equal(t, int64(12), update.LastUpdateID)
+	assert.True(t, update.Bids[0][1].IsZero())
+
+	_, err = spot.SubscribeMarkPrice("BTC-USDT", func(MarkPriceEvent) {})
+	assert.ErrorIs(t, err, ErrStreamUnsupported)
+
+	require.NoError(t, sub.Unsubscribe())
+	assert.Equal(t, "BTC-USDT@trade", receive(t, unsubs))
+}
+
+func TestSwapMarketStreams(t *testing.T) {
+	ws := newStreamServer(t, map[string]string{
+		"BTC-USDT@trade":        `[{"q":"0.1","p":"37000","T":1,"m":false,"s":"BTC-USDT"},{"q":"0.2","p":"37001","T":2,"m":true,"s":"BTC-USDT"}]`,
+		"BTC-USDT@kline_1m":     `[{"c":"2","o":"1","h":"3","l":"0.5","v":"10","T":1700000000000}]`,
+		"BTC-USDT@depth5@500ms": `{"bids":[["36999","1.5"]],"asks":[["37001","0.25"]]}`,
+		"BTC-USDT@markPrice":    `{"e":"markPriceUpdate","E":5,"s":"BTC-USDT","p":"37000.12"}`,
+		"BTC-USDT@ticker":       `{"e":"24hTicker","E":7,"s":"BTC-USDT","p":"100","P":"0.27","c":"37000","L":"0.01","h":"37100","l":"36800","v":"12","q":"444000","o":"36900","O":1,"C":2}`,
+	}, nil)
+	swap := NewSwapMarketStream(ws)
+
+	trades := make(chan TradeEvent, 2)
+	_, err := swap.SubscribeTrades("BTC-USDT", func(e TradeEvent) { trades <- e })
+	require.NoError(t, err)
+	assert.Equal(t, "37000", receive(t, trades).Price.String())
+	assert.Equal(t, "0.2", receive(t, trades).Volume.String())
+
+	klines := make(chan KlineEvent, 1)
+	_, err = swap.SubscribeKlines("BTC-USDT", "1m", func(e KlineEvent) { klines <- e })
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000000), receive(t, klines).Time)
+
+	depths := make(chan DepthEvent, 1)
+	_, err = swap.SubscribeDepth("BTC-USDT", 5, func(e DepthEvent) { depths <- e })
+	require.NoError(t, err)
+	assert.Len(t, receive(t, depths).Bids, 1)
+
+	marks := make(chan MarkPriceEvent, 1)
+	_, err = swap.SubscribeMarkPrice("BTC-USDT", func(e MarkPriceEvent) { marks <- e })
+	require.NoError(t, err)
+	assert.Equal(t, "37000.12", receive(t, marks).MarkPrice.String())
+
+	tickers := make(chan TickerEvent, 1)
+	_, err = swap.SubscribeTicker("BTC-USDT", func(e TickerEvent) { tickers <- e })
+	require.NoError(t, err)
+	ticker := receive(t, tickers)
+	// "L" is the last quantity and must not be taken for the low price.
+	assert.Equal(t, "36800", ticker.Low.String())
+}
+
+func TestSubscriptionsShareStream(t *testing.T) {
+	newClients := map[string]func(t *testing.T, url string) StreamSubscriber{
+		"client": func(t *testing.T, url string) StreamSubscriber {
+			ws := NewWebsocketClient(url)
+			t.Cleanup(func() { ws.Close() })
+			return ws
+		},
+		"pool": func(t *testing.T, url string) StreamSubscriber {
+			pool := NewWebsocketPool(url)
+			t.Cleanup(func() { pool.Close() })
+			return pool
+		},
+	}
+	for name, newClient := range newClients {
+		t.Run(name, func(t *testing.T) {
+			push := make(chan string)
+			requests := make(chan string, 4)
+			url := newTestWSServer(t, func(conn *websocket.Conn) {
+				reqs := make(chan map[string]string)
+				go func() {
+					defer close(reqs)
+					for {
+						var req map[string]string
+						if err := conn.ReadJSON(&req); err != nil {
+							return
+						}
+						reqs <- req
+					}
+				}()
+				for {
+					select {
+					case req, ok := <-reqs:
+						if !ok {
+							return
+						}
+						writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 0})
+						requests <- req["reqType"] + " " + req["dataType"]
+					case id := <-push:
+						writeGzip(conn, []byte(`{"dataType":"BTC-USDT@trade","data":{"s":"BTC-USDT","t":"`+id+`","p":"1","q":"1"}}`))
+					}
+				}
+			})
+			ws := newClient(t, url)
+			// Keeps the pool from closing the connection with the last stream.
+			require.NoError(t, ws.SubscribeCtx(context.Background(), []string{"ETH-USDT@trade"}, func([]byte) {}))
+			assert.Equal(t, "sub ETH-USDT@trade", receive(t, requests))
+			spot := NewSpotMarketStream(ws)
+
+			first, second := make(chan TradeEvent, 2), make(chan TradeEvent, 2)
+			sub1, err := spot.SubscribeTrades("BTC-USDT", func(e TradeEvent) { first <- e })
+			require.NoError(t, err)
+			sub2, err := spot.SubscribeTrades("BTC-USDT", func(e TradeEvent) { second <- e })
+			require.NoError(t, err)
+			assert.Equal(t, "sub BTC-USDT@trade", receive(t, requests))
+
+			push <- "1"
+			assert.Equal(t, "1", receive(t, first).TradeId)
+			assert.Equal(t, "1", receive(t, second).TradeId)
+
+			// The stream stays subscribed while a consumer is left.
+			require.NoError(t, sub1.Unsubscribe())
+			push <- "2"
+			assert.Equal(t, "2", receive(t, second).TradeId)
+			assert.Empty(t, first)
+			assert.Empty(t, requests)
+
+			require.NoError(t, sub2.Unsubscribe())
+			assert.Equal(t, "unsub BTC-USDT@trade", receive(t, requests))
+		})
+	}
+}
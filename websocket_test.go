@@ -0,0 +1,266 @@
+package bingxgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestWSServer serves each connection with serve and returns the ws:// URL.
+func newTestWSServer(t *testing.T, serve func(conn *websocket.Conn)) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// writeGzip sends v gzip-compressed, as BingX does.
+func writeGzip(conn *websocket.Conn, v interface{}) error {
+	data, ok := v.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return conn.WriteMessage(websocket.BinaryMessage, buf.Bytes())
+}
+
+// readSubscription reads one subscription request and accepts it.
+func readSubscription(t *testing.T, conn *websocket.Conn) map[string]string {
+	t.Helper()
+	var req map[string]string
+	if err := conn.ReadJSON(&req); err != nil {
+		return nil
+	}
+	writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 0, "msg": ""})
+	return req
+}
+
+func TestWebsocketDispatchesAndAnswersPing(t *testing.T) {
+	pong := make(chan string, 2)
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		req := readSubscription(t, conn)
+		assert.Equal(t, "sub", req["reqType"])
+		assert.Equal(t, "BTC-USDT@trade", req["dataType"])
+
+		writeGzip(conn, map[string]interface{}{"dataType": "ETH-USDT@trade", "data": map[string]string{"p": "1"}})
+		writeGzip(conn, map[string]interface{}{"dataType": "BTC-USDT@trade", "data": map[string]string{"p": "2"}})
+
+		writeGzip(conn, []byte("Ping"))
+		_, data, _ := conn.ReadMessage()
+		pong <- string(data)
+
+		writeGzip(conn, map[string]string{"ping": "abc", "time": "2024-01-01T00:00:00Z"})
+		_, data, _ = conn.ReadMessage()
+		pong <- string(data)
+
+		conn.ReadMessage()
+	})
+
+	c := NewWebsocketClient(url)
+	received := make(chan []byte, 1)
+	require.NoError(t, c.Subscribe([]string{"BTC-USDT@trade"}, func(msg []byte) {
+		received <- msg
+	}))
+
+	var msg wsMessage
+	require.NoError(t, json.Unmarshal(<-received, &msg))
+	assert.Equal(t, "BTC-USDT@trade", msg.DataType)
+	assert.JSONEq(t, `{"p":"2"}`, string(msg.Data))
+
+	assert.Equal(t, "Pong", <-pong)
+	assert.JSONEq(t, `{"pong":"abc","time":"2024-01-01T00:00:00Z"}`, <-pong)
+
+	require.NoError(t, c.Close())
+	<-c.Done()
+	assert.NoError(t, c.Err())
+	assert.ErrorIs(t, c.Subscribe([]string{"BTC-USDT@depth"}, func([]byte) {}), ErrWebsocketClosed)
+}
+
+func TestWebsocketSubscribeRejected(t *testing.T) {
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		var req map[string]string
+		conn.ReadJSON(&req)
+		writeGzip(conn, map[string]interface{}{"id": req["id"], "code": 80015, "msg": "dataType not supported"})
+		conn.ReadMessage()
+	})
+
+	c := NewWebsocketClient(url)
+	defer c.Close()
+	err := c.Subscribe([]string{"BTC-USDT@nothing"}, func([]byte) {})
+	assert.Equal(t, 80015, ErrorCode(err))
+}
+
+func TestWebsocketRequest(t *testing.T) {
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		for {
+			var req struct {
+				ID      string          `json:"id"`
+				ReqType string          `json:"reqType"`
+				Data    json.RawMessage `json:"data"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			switch req.ReqType {
+			case "echo":
+				writeGzip(conn, map[string]interface{}{"id": req.ID, "code": 0, "data": req.Data})
+			case "reject":
+				writeGzip(conn, map[string]interface{}{"id": req.ID, "code": 100202, "msg": "insufficient balance"})
+			case "close":
+				return
+			}
+			// "ignore" is never answered.
+		}
+	})
+	c := NewWebsocketClient(url, WithRequestTimeout(50*time.Millisecond))
+	defer c.Close()
+
+	data, err := c.Request("echo", map[string]string{"symbol": "BTC-USDT"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"symbol":"BTC-USDT"}`, string(data))
+
+	_, err = c.Request("reject", nil)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.Equal(t, 100202, ErrorCode(err))
+
+	_, err = c.Request("ignore", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = c.Request("close", nil)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, ErrConnectionLost)
+	assert.Equal(t, "close", apiErr.Endpoint)
+}
+
+func TestWebsocketCloseDuringDial(t *testing.T) {
+	release := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.ReadMessage()
+	}))
+	defer srv.Close()
+	var releaseOnce sync.Once
+	defer releaseOnce.Do(func() { close(release) })
+
+	c := NewWebsocketClient("ws" + strings.TrimPrefix(srv.URL, "http"))
+	connected := make(chan error, 1)
+	go func() { connected <- c.Connect() }()
+	time.Sleep(50 * time.Millisecond)
+
+	// The handshake hangs, Close must not wait for it.
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	assert.NoError(t, receive(t, closed))
+	releaseOnce.Do(func() { close(release) })
+	assert.ErrorIs(t, receive(t, connected), ErrWebsocketClosed)
+}
+
+func TestWebsocketErrOnConnectionLoss(t *testing.T) {
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		readSubscription(t, conn)
+	})
+
+	c := NewWebsocketClient(url, WithReconnectPolicy(nil))
+	require.NoError(t, c.Subscribe([]string{"BTC-USDT@trade"}, func([]byte) {}))
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("client did not stop")
+	}
+	assert.Error(t, c.Err())
+}
+
+func TestWebsocketReconnectsAndResubscribes(t *testing.T) {
+	var conns atomic.Int32
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		req := readSubscription(t, conn)
+		if conns.Add(1) == 1 {
+			// Drop the first connection right after subscribing.
+			return
+		}
+		writeGzip(conn, map[string]interface{}{"dataType": req["dataType"], "data": map[string]string{"p": "1"}})
+		conn.ReadMessage()
+	})
+
+	events := make(chan WebsocketEvent, 8)
+	c := NewWebsocketClient(url, WithReconnectPolicy(&ReconnectPolicy{InitialBackoff: 10 * time.Millisecond}))
+	defer c.Close()
+	c.OnEvent(func(e WebsocketEvent) { events <- e })
+
+	received := make(chan []byte, 1)
+	require.NoError(t, c.Subscribe([]string{"BTC-USDT@trade"}, func(msg []byte) {
+		received <- msg
+	}))
+
+	var kinds []WebsocketEventKind
+	for len(kinds) < 4 {
+		e := <-events
+		kinds = append(kinds, e.Kind)
+		switch e.Kind {
+		case WebsocketDisconnected:
+			assert.Error(t, e.Err)
+		case WebsocketResubscribed:
+			assert.NoError(t, e.Err)
+			assert.Equal(t, []string{"BTC-USDT@trade"}, e.Streams)
+		}
+	}
+	assert.Equal(t, []WebsocketEventKind{
+		WebsocketConnected, WebsocketDisconnected, WebsocketConnected, WebsocketResubscribed,
+	}, kinds)
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("no message after resubscription")
+	}
+}
+
+func TestWebsocketDetectsStaleConnection(t *testing.T) {
+	url := newTestWSServer(t, func(conn *websocket.Conn) {
+		readSubscription(t, conn)
+		// Stay silent, as a half-open connection would.
+		conn.ReadMessage()
+	})
+
+	c := NewWebsocketClient(url, WithReconnectPolicy(nil), WithHeartbeatTimeout(50*time.Millisecond))
+	require.NoError(t, c.Subscribe([]string{"BTC-USDT@trade"}, func([]byte) {}))
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stale connection not detected")
+	}
+	assert.ErrorIs(t, c.Err(), ErrStaleConnection)
+}